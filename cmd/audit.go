@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func init() {
+	auditCmd.Flags().String("state", "", "Only show updates of this state, or of the pinned summary with "+store.SummaryAudit)
+	auditCmd.Flags().String("from", "", "Only show updates published at or after this RFC3339 time")
+	auditCmd.Flags().String("to", "", "Only show updates published at or before this RFC3339 time")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show what the bot published and when",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetOutput(os.Stderr)
+
+		state, _ := cmd.Flags().GetString("state")
+
+		from, err := parseTimeFlag(cmd, "from")
+
+		if err != nil {
+			return err
+		}
+
+		to, err := parseTimeFlag(cmd, "to")
+
+		if err != nil {
+			return err
+		}
+
+		s, err := newStore()
+
+		if err != nil {
+			return err
+		}
+
+		entries, err := s.GetAudit(state, from, to)
+
+		if err != nil {
+			return errors.Wrap(err, "could not read the audit log")
+		}
+
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(table, "Time\tState\tTotal\tReporting\tCounts\tHash\tTargets")
+
+		for _, entry := range entries {
+			failed, unchanged := 0, 0
+			for _, target := range entry.Targets {
+				if target.Error != "" {
+					failed++
+				} else if target.Unchanged {
+					unchanged++
+				}
+			}
+
+			_, _ = fmt.Fprintf(table, "%s\t%s\t%d\t%.2f%%\t%v\t%.12s\t%d sent, %d unchanged, %d failed\n",
+				entry.Time.Format(time.RFC3339), entry.State, entry.TotalVotes, entry.ReportingPercentage*100,
+				entry.Counts, entry.TextHash, len(entry.Targets)-failed-unchanged, unchanged, failed)
+
+			for _, target := range entry.Targets {
+				if target.Error != "" {
+					_, _ = fmt.Fprintf(table, "\t\t\t\t\t\t%s: %s\n", target.MessageID, target.Error)
+				}
+			}
+		}
+
+		return table.Flush()
+	},
+}
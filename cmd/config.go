@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/config"
+	"github.com/aaomidi/uselections-2020/redis"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/aaomidi/uselections-2020/telegram"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate the configuration and check that redis and telegram are reachable",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := json.MarshalIndent(config.Redacted(), "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Effective configuration:\n%s\n\n", settings)
+
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+
+		fmt.Println("✓ configuration is valid")
+
+		s, err := newStore()
+
+		if err != nil {
+			return errors.Wrap(err, "store")
+		}
+
+		if r, ok := s.(*redis.Redis); ok {
+			if err := r.Ping(); err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ redis is reachable at %s:%d\n", cfg.Redis.Host, cfg.Redis.Port)
+		} else {
+			fmt.Printf("✓ %s store opened\n", cfg.Store)
+		}
+
+		tg := telegram.New(cfg.Token, cfg.Channel, store.NewMemory(), nil, telegram.Settings{APIURL: cfg.APIURL})
+
+		if err := tg.Create(); err != nil {
+			return errors.Wrap(err, "telegram")
+		}
+
+		fmt.Printf("✓ telegram token works and %s resolved\n", cfg.Channel)
+
+		return nil
+	},
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/export"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"os"
+	"time"
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "Show what changed between two snapshots",
+	Long: `Show what changed between two snapshots.
+
+Each snapshot is either a file written by "export --format snapshot", in which case
+its last snapshot is used, or an RFC3339 time, in which case the last recorded
+snapshot at or before that time is read from the store.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetOutput(os.Stderr)
+
+		var s store.Store
+
+		snapshots := make([]election.Snapshot, 0, 2)
+		for _, arg := range args {
+			if _, err := time.Parse(time.RFC3339, arg); err == nil && s == nil {
+				opened, err := newStore()
+
+				if err != nil {
+					return err
+				}
+
+				s = opened
+			}
+
+			snapshot, err := loadSnapshot(s, arg)
+
+			if err != nil {
+				return errors.Wrapf(err, "could not load snapshot %s", arg)
+			}
+
+			snapshots = append(snapshots, snapshot)
+		}
+
+		printDiff(snapshots[0], snapshots[1])
+
+		return nil
+	},
+}
+
+// loadSnapshot reads the last snapshot of a file, or the last snapshot in the store at or before an RFC3339 time
+func loadSnapshot(s store.Store, arg string) (election.Snapshot, error) {
+	if at, err := time.Parse(time.RFC3339, arg); err == nil {
+		history, err := s.GetSnapshots(time.Time{}, at)
+
+		if err != nil {
+			return election.Snapshot{}, err
+		}
+
+		if len(history) == 0 {
+			return election.Snapshot{}, fmt.Errorf("nothing recorded before %s", at)
+		}
+
+		return history[len(history)-1], nil
+	}
+
+	file, err := os.Open(arg)
+
+	if err != nil {
+		return election.Snapshot{}, err
+	}
+
+	defer file.Close()
+
+	snapshots, err := export.ReadSnapshots(file)
+
+	if err != nil {
+		return election.Snapshot{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return election.Snapshot{}, fmt.Errorf("%s holds no snapshots", arg)
+	}
+
+	return snapshots[len(snapshots)-1], nil
+}
+
+func printDiff(before election.Snapshot, after election.Snapshot) {
+	p := message.NewPrinter(language.English)
+
+	p.Printf("before: %s\nafter:  %s\n", before.Time.Format(time.RFC3339), after.Time.Format(time.RFC3339))
+
+	diff := election.Diff(before.Votes, after.Votes)
+
+	if len(diff) == 0 {
+		p.Println("\nno changes")
+		return
+	}
+
+	for _, state := range diff {
+		switch {
+		case state.Added:
+			p.Printf("\n%s (new)\n", state.State.Name)
+		case state.Removed:
+			p.Printf("\n%s (removed)\n", state.State.Name)
+		default:
+			p.Printf("\n%s\n", state.State.Name)
+		}
+
+		p.Printf("  total     %12d -> %12d  %+d\n", state.TotalBefore, state.TotalAfter, state.Votes())
+		p.Printf("  reporting %12d -> %12d  (%.2f%% -> %.2f%%)\n", state.ReportingBefore, state.ReportingAfter,
+			state.ReportingPercentBefore*100, state.ReportingPercentAfter*100)
+
+		for _, c := range state.Candidates {
+			note := ""
+			switch {
+			case c.Added:
+				note = " (new)"
+			case c.Removed:
+				note = " (removed)"
+			}
+
+			p.Printf("  %-9s %12d -> %12d  %+d (%.2f%% -> %.2f%%)%s\n", c.Candidate.LastName, c.CountBefore, c.CountAfter,
+				c.Votes(), c.PercentageBefore*100, c.PercentageAfter*100, note)
+		}
+	}
+}
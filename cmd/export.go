@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/export"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	exportCmd.Flags().String("format", "csv", "Output format: "+strings.Join(export.Formats, ", "))
+	exportCmd.Flags().String("output", "-", "File to write to, - for stdout")
+	exportCmd.Flags().Bool("history", false, "Export the whole recorded history instead of the latest snapshot")
+	exportCmd.Flags().StringSlice("state", nil, "Only export these states")
+	exportCmd.Flags().String("from", "", "Only export snapshots taken at or after this RFC3339 time")
+	exportCmd.Flags().String("to", "", "Only export snapshots taken at or before this RFC3339 time")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the latest or recorded results",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		history, _ := cmd.Flags().GetBool("history")
+		states, _ := cmd.Flags().GetStringSlice("state")
+
+		// checked up front, so a typo doesn't truncate the output file
+		if !export.IsFormat(format) {
+			return fmt.Errorf("--format %q is not one of %s", format, strings.Join(export.Formats, ", "))
+		}
+
+		if output == "-" {
+			// keep the logs out of the export
+			log.SetOutput(os.Stderr)
+		}
+
+		filter := export.Filter{
+			States: states,
+		}
+
+		var err error
+		if filter.From, err = parseTimeFlag(cmd, "from"); err != nil {
+			return err
+		}
+
+		if filter.To, err = parseTimeFlag(cmd, "to"); err != nil {
+			return err
+		}
+
+		s, err := newStore()
+
+		if err != nil {
+			return err
+		}
+
+		var snapshots []election.Snapshot
+
+		if history || !filter.From.IsZero() || !filter.To.IsZero() {
+			snapshots, err = s.GetSnapshots(filter.From, filter.To)
+		} else {
+			var latest election.Snapshot
+			latest, err = s.GetLatestSnapshot()
+			snapshots = []election.Snapshot{latest}
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "could not read snapshots")
+		}
+
+		var w io.Writer = os.Stdout
+
+		if output != "-" {
+			file, err := os.Create(output)
+
+			if err != nil {
+				return err
+			}
+
+			defer file.Close()
+
+			w = file
+		}
+
+		if format == "snapshot" {
+			return export.WriteSnapshots(w, export.Snapshots(snapshots, filter))
+		}
+
+		return export.Write(w, format, export.Rows(snapshots, filter))
+	},
+}
+
+// parseTimeFlag parses an RFC3339 time flag, an unset flag is the zero time
+func parseTimeFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, _ := cmd.Flags().GetString(name)
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "--%s is not an RFC3339 time", name)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/scraper/nprtest"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func init() {
+	fakeNPRCmd.Flags().String("scenario", "lead-flip", "Scenario to play: "+strings.Join(nprtest.ScenarioNames(), ", "))
+	fakeNPRCmd.Flags().String("listen", ":8081", "Address to serve the scenario on")
+
+	rootCmd.AddCommand(fakeNPRCmd)
+}
+
+var fakeNPRCmd = &cobra.Command{
+	Use:   "fake-npr",
+	Short: "Serve a scripted scenario in place of NPR, point --scrape-base-url at it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("scenario")
+		listen, _ := cmd.Flags().GetString("listen")
+
+		scenario, ok := nprtest.GetScenario(name)
+
+		if !ok {
+			return fmt.Errorf("--scenario %q is not one of %s", name, strings.Join(nprtest.ScenarioNames(), ", "))
+		}
+
+		server := &http.Server{
+			Addr:    listen,
+			Handler: nprtest.Handler(scenario),
+		}
+
+		terminate := make(chan os.Signal, 1)
+		signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+		go func() {
+			<-terminate
+
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_ = server.Shutdown(shutdown)
+		}()
+
+		log.Infof("playing %s on %s", scenario.Name, listen)
+
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+
+		return nil
+	},
+}
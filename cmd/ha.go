@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/redis"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"time"
+)
+
+// holderID identifies this replica in the leader lease
+func holderID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+}
+
+// lead blocks until this replica holds the lease, or ctx is done. Once it's the leader it keeps renewing
+// the lease in the background, and closes the returned channel when it fails to and might not be the leader anymore.
+func lead(ctx context.Context, lease *redis.Lease) (<-chan struct{}, error) {
+	logger := log.WithField("source", "ha")
+	interval := lease.TTL() / 3
+
+	logger.Info("standing by until we hold the leader lease")
+
+	for {
+		held, err := lease.Acquire(ctx)
+
+		if err != nil {
+			logger.WithError(err).Warn("could not acquire the leader lease")
+		}
+
+		if held {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	logger.Info("we are the leader")
+
+	lost := make(chan struct{})
+
+	go func() {
+		defer close(lost)
+
+		// the lease is ours until lastRenewal+ttl, after that a standby may have taken it
+		lastRenewal := time.Now()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			held, err := lease.Acquire(ctx)
+
+			switch {
+			case err != nil && time.Since(lastRenewal) < lease.TTL()-interval:
+				logger.WithError(err).Warn("could not renew the leader lease, retrying")
+			case err != nil:
+				logger.WithError(err).Error("could not renew the leader lease in time")
+				return
+			case !held:
+				logger.Error("another replica took the leader lease")
+				return
+			default:
+				lastRenewal = time.Now()
+			}
+		}
+	}()
+
+	return lost, nil
+}
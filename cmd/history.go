@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/store"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// recordHistory saves every broadcast snapshot to the store, so it can be exported and diffed later
+func recordHistory(s store.Store, d *data.Data) {
+	updates := make(chan data.OutgoingUpdate, 2)
+	d.RegisterDataReceiver(updates)
+
+	logger := log.WithField("source", "history")
+
+	go func() {
+		for update := range updates {
+			if update.Quarantined {
+				continue
+			}
+
+			err := s.SaveSnapshot(election.Snapshot{
+				Time:  time.Now(),
+				Votes: update.Votes,
+			})
+
+			if err != nil {
+				logger.WithError(err).Warn("failed recording snapshot")
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/redis"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	migrateCmd.Flags().Bool("dry-run", false, "Only show what would be moved")
+
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the Redis keys of older versions to the current layout",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if cfg.Store != "redis" {
+			return fmt.Errorf("only the redis store has migrations, not %q", cfg.Store)
+		}
+
+		if cfg.Token == "" {
+			return fmt.Errorf("token: required to scope the inline message IDs to the bot")
+		}
+
+		r, err := redis.New(cfg.Redis.URL(), cfg.Redis.Prefix, cfg.BotID())
+
+		if err != nil {
+			return err
+		}
+
+		version, err := r.GetSchemaVersion()
+
+		if err != nil {
+			return err
+		}
+
+		renames, err := r.Migrate(dryRun)
+
+		if err != nil {
+			return err
+		}
+
+		for _, rename := range renames {
+			fmt.Printf("%s -> %s\n", rename.From, rename.To)
+		}
+
+		if dryRun {
+			fmt.Printf("would move %d keys from schema %d to %d\n", len(renames), version, redis.SchemaVersion)
+			return nil
+		}
+
+		fmt.Printf("moved %d keys from schema %d to %d\n", len(renames), version, redis.SchemaVersion)
+
+		return nil
+	},
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/scraper"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/aaomidi/uselections-2020/telegram"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+func init() {
+	rcvCmd.Flags().String("state", "ME", "Abbreviation of the state the contest is in")
+	rcvCmd.Flags().Bool("publish", false, "Post the results to the channel instead of printing them")
+
+	rootCmd.AddCommand(rcvCmd)
+}
+
+var rcvCmd = &cobra.Command{
+	Use:   "rcv <summary.json>",
+	Short: "Render the round by round results of a ranked choice contest from an RCTab summary",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		abbreviation, _ := cmd.Flags().GetString("state")
+		publish, _ := cmd.Flags().GetBool("publish")
+
+		// the message owns stdout
+		log.SetOutput(os.Stderr)
+
+		abbreviation = strings.ToUpper(abbreviation)
+		state, ok := election.GetIndexStates()[abbreviation]
+
+		if !ok {
+			state = election.State{Name: abbreviation, Abbreviation: abbreviation}
+		}
+
+		f, err := os.Open(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		contest, err := scraper.ParseRCTab(f, state)
+
+		if err != nil {
+			return errors.Wrap(err, args[0])
+		}
+
+		if !publish {
+			fmt.Println(telegram.GetRCVMessage(contest))
+			return nil
+		}
+
+		tg := telegram.New(cfg.Token, cfg.Channel, store.NewMemory(), nil, telegram.Settings{APIURL: cfg.APIURL})
+
+		if err := tg.Create(); err != nil {
+			return errors.Wrap(err, "error creating telegram bot")
+		}
+
+		return tg.PublishRCV(contest)
+	},
+}
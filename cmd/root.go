@@ -1,33 +1,81 @@
 package cmd
 
 import (
+	"github.com/aaomidi/uselections-2020/config"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"strings"
+	"time"
 )
 
+// cfg is the configuration, loaded before any command runs
+var cfg *config.Config
+
 var rootCmd = &cobra.Command{
-	Use: "bot",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	Use:          "bot",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		viper.SetConfigName("config")
 		viper.AddConfigPath(".")
 		viper.SetEnvPrefix("bot")
+		viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 		viper.AutomaticEnv()
 
-		_ = viper.ReadInConfig()
+		if err := viper.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return errors.Wrap(err, "unable to read config file")
+			}
+		}
+
+		c, err := config.Load()
+
+		if err != nil {
+			return err
+		}
 
-		level, err := log.ParseLevel(viper.GetString("log"))
+		cfg = c
+
+		level, err := log.ParseLevel(cfg.Log)
 
 		if err != nil {
-			panic(err)
+			return errors.Wrapf(err, "log: %q is not a log level", cfg.Log)
 		}
 
 		log.SetFormatter(&log.TextFormatter{
-			ForceColors: viper.GetBool("colors"),
+			ForceColors: cfg.Colors,
 		})
 		log.SetOutput(os.Stdout)
 		log.SetLevel(level)
+
+		if cfg.Election != "" {
+			definition, err := config.LoadElection(cfg.Election)
+
+			if err != nil {
+				return err
+			}
+
+			if err := election.Use(definition); err != nil {
+				return err
+			}
+		}
+
+		// only the election knows what its race compares to, there may be nothing
+		if cfg.CompareYear != 0 && !election.HasBaseline(cfg.CompareYear) {
+			log.Warnf("compare-year: %s has no results of %d to compare against, leaving the comparison out", election.GetName(), cfg.CompareYear)
+			cfg.CompareYear = 0
+		}
+
+		if cfg.PopulationFile != "" {
+			if err := election.LoadPopulations(cfg.PopulationFile); err != nil {
+				return errors.Wrap(err, "population-file")
+			}
+		}
+
+		return nil
 	},
 }
 
@@ -43,7 +91,8 @@ func Execute() {
 	}
 
 	if err := rootCmd.Execute(); err != nil {
-		panic(err)
+		// cobra already printed the error
+		os.Exit(1)
 	}
 }
 
@@ -53,18 +102,96 @@ func init() {
 
 	rootCmd.PersistentFlags().String("token", "", "Telegram bot API token")
 	rootCmd.PersistentFlags().String("channel", "", "Telegram channel ID")
+	rootCmd.PersistentFlags().IntSlice("admins", nil, "Telegram user IDs allowed to run admin commands")
+	rootCmd.PersistentFlags().String("api-url", "", "Telegram Bot API server, for a local one, instead of api.telegram.org")
+
+	rootCmd.PersistentFlags().Bool("webhook", false, "Receive Telegram updates through a webhook instead of long polling")
+	rootCmd.PersistentFlags().String("webhook-listen", ":8443", "Address the webhook listener binds to")
+	rootCmd.PersistentFlags().String("webhook-public-url", "", "HTTPS URL Telegram reaches the webhook listener on, without the secret path")
+	rootCmd.PersistentFlags().String("webhook-secret-path", "", "Path updates are accepted on, random on every start when empty")
+	rootCmd.PersistentFlags().String("webhook-tls-cert", "", "Certificate file to serve the webhook over HTTPS, leave empty behind a TLS proxy")
+	rootCmd.PersistentFlags().String("webhook-tls-key", "", "Key file of the webhook certificate")
+	rootCmd.PersistentFlags().Bool("webhook-upload-cert", false, "Send the webhook certificate to Telegram, for self signed certificates")
+
+	rootCmd.PersistentFlags().String("scrape-base-url", "", "Serve the NPR data files from this server instead of NPR's, for a local fake")
+	rootCmd.PersistentFlags().Duration("scrape-timeout", 10*time.Second, "Timeout of a single request to the results source")
+	rootCmd.PersistentFlags().Duration("scrape-interval", 5*time.Second, "Initial wait between two scrapes")
+	rootCmd.PersistentFlags().Duration("scrape-min", 2*time.Second, "Shortest wait between two scrapes")
+	rootCmd.PersistentFlags().Duration("scrape-max", time.Minute, "Longest wait between two scrapes")
+	rootCmd.PersistentFlags().Float64("scrape-jitter", 0.2, "Fraction of the scrape wait that is randomised")
+	rootCmd.PersistentFlags().Duration("scrape-fast-window", 30*time.Minute, "How long to scrape at the shortest wait after polls close")
+
+	rootCmd.PersistentFlags().Float64("percent-tolerance", 0.02, "Hold back states whose percentages add up further than this from 100%")
+	rootCmd.PersistentFlags().Float64("max-jump", 0.5, "Hold back states whose total grows by more than this fraction in one scrape")
+	rootCmd.PersistentFlags().Int64("min-jump-votes", 50000, "Growth in votes that is always plausible, whatever the fraction")
+
+	rootCmd.PersistentFlags().String("election", "", "Election definition to cover, a file or a name in elections/, the 2020 presidential election when empty")
+
+	rootCmd.PersistentFlags().Float64("close-margin", 0.01, "Margin under which an uncalled state is too close to call, as a fraction of all votes")
+	rootCmd.PersistentFlags().Float64("recount-reporting", 0.9, "Share of precincts a state needs reporting before it can set off a recount alert, called states always can")
+	rootCmd.PersistentFlags().Int("compare-year", 2016, "Compare states against this previous cycle in messages, 0 to turn off")
+	rootCmd.PersistentFlags().String("population-file", "", "JSON file with the voting eligible population of every state, replacing the election's")
+
+	rootCmd.PersistentFlags().Bool("counties", false, "Also scrape county level results")
+	rootCmd.PersistentFlags().String("county-url", "", "URL of the per state county files, %s is replaced with the state abbreviation")
+
+	rootCmd.PersistentFlags().Bool("ha", false, "Run as one of several replicas, only the leader talks to Telegram")
+	rootCmd.PersistentFlags().Duration("ha-lease-ttl", 15*time.Second, "How long the leader lease lasts without being renewed")
+
+	rootCmd.PersistentFlags().String("store", "redis", "Storage backend: redis, file or memory")
+	rootCmd.PersistentFlags().String("store-path", "store.json", "Path of the store when using the file backend")
 
 	rootCmd.PersistentFlags().String("redis-host", "127.0.0.1", "Redis Host")
 	rootCmd.PersistentFlags().Int("redis-port", 6379, "Redis Port")
 	rootCmd.PersistentFlags().Int("redis-db", 0, "Redis DB")
+	rootCmd.PersistentFlags().String("redis-prefix", "uselections", "Prefix of every Redis key")
 
 	_ = viper.BindPFlag("log", rootCmd.PersistentFlags().Lookup("log"))
 	_ = viper.BindPFlag("colors", rootCmd.PersistentFlags().Lookup("colors"))
 
 	_ = viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 	_ = viper.BindPFlag("channel", rootCmd.PersistentFlags().Lookup("channel"))
+	_ = viper.BindPFlag("admins", rootCmd.PersistentFlags().Lookup("admins"))
+	_ = viper.BindPFlag("api-url", rootCmd.PersistentFlags().Lookup("api-url"))
+
+	_ = viper.BindPFlag("webhook.enabled", rootCmd.PersistentFlags().Lookup("webhook"))
+	_ = viper.BindPFlag("webhook.listen", rootCmd.PersistentFlags().Lookup("webhook-listen"))
+	_ = viper.BindPFlag("webhook.public-url", rootCmd.PersistentFlags().Lookup("webhook-public-url"))
+	_ = viper.BindPFlag("webhook.secret-path", rootCmd.PersistentFlags().Lookup("webhook-secret-path"))
+	_ = viper.BindPFlag("webhook.tls-cert", rootCmd.PersistentFlags().Lookup("webhook-tls-cert"))
+	_ = viper.BindPFlag("webhook.tls-key", rootCmd.PersistentFlags().Lookup("webhook-tls-key"))
+	_ = viper.BindPFlag("webhook.upload-cert", rootCmd.PersistentFlags().Lookup("webhook-upload-cert"))
+
+	_ = viper.BindPFlag("scrape.base-url", rootCmd.PersistentFlags().Lookup("scrape-base-url"))
+	_ = viper.BindPFlag("scrape.timeout", rootCmd.PersistentFlags().Lookup("scrape-timeout"))
+	_ = viper.BindPFlag("scrape.interval", rootCmd.PersistentFlags().Lookup("scrape-interval"))
+	_ = viper.BindPFlag("scrape.min", rootCmd.PersistentFlags().Lookup("scrape-min"))
+	_ = viper.BindPFlag("scrape.max", rootCmd.PersistentFlags().Lookup("scrape-max"))
+	_ = viper.BindPFlag("scrape.jitter", rootCmd.PersistentFlags().Lookup("scrape-jitter"))
+	_ = viper.BindPFlag("scrape.fast-window", rootCmd.PersistentFlags().Lookup("scrape-fast-window"))
+
+	_ = viper.BindPFlag("validation.percent-tolerance", rootCmd.PersistentFlags().Lookup("percent-tolerance"))
+	_ = viper.BindPFlag("validation.max-jump", rootCmd.PersistentFlags().Lookup("max-jump"))
+	_ = viper.BindPFlag("validation.min-jump-votes", rootCmd.PersistentFlags().Lookup("min-jump-votes"))
+
+	_ = viper.BindPFlag("election", rootCmd.PersistentFlags().Lookup("election"))
+
+	_ = viper.BindPFlag("close-margin", rootCmd.PersistentFlags().Lookup("close-margin"))
+	_ = viper.BindPFlag("recount-reporting", rootCmd.PersistentFlags().Lookup("recount-reporting"))
+	_ = viper.BindPFlag("compare-year", rootCmd.PersistentFlags().Lookup("compare-year"))
+	_ = viper.BindPFlag("population-file", rootCmd.PersistentFlags().Lookup("population-file"))
+
+	_ = viper.BindPFlag("counties.enabled", rootCmd.PersistentFlags().Lookup("counties"))
+	_ = viper.BindPFlag("counties.url", rootCmd.PersistentFlags().Lookup("county-url"))
+
+	_ = viper.BindPFlag("ha.enabled", rootCmd.PersistentFlags().Lookup("ha"))
+	_ = viper.BindPFlag("ha.lease-ttl", rootCmd.PersistentFlags().Lookup("ha-lease-ttl"))
+
+	_ = viper.BindPFlag("store", rootCmd.PersistentFlags().Lookup("store"))
+	_ = viper.BindPFlag("store-path", rootCmd.PersistentFlags().Lookup("store-path"))
 
 	_ = viper.BindPFlag("redis.host", rootCmd.PersistentFlags().Lookup("redis-host"))
 	_ = viper.BindPFlag("redis.port", rootCmd.PersistentFlags().Lookup("redis-port"))
 	_ = viper.BindPFlag("redis.db", rootCmd.PersistentFlags().Lookup("redis-db"))
+	_ = viper.BindPFlag("redis.prefix", rootCmd.PersistentFlags().Lookup("redis-prefix"))
 }
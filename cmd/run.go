@@ -1,14 +1,12 @@
 package cmd
 
 import (
-	"fmt"
-	"github.com/aaomidi/uselections-2020/data"
+	"context"
 	"github.com/aaomidi/uselections-2020/redis"
-	scraper2 "github.com/aaomidi/uselections-2020/scraper"
 	"github.com/aaomidi/uselections-2020/telegram"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"os"
 	"os/signal"
 	"syscall"
@@ -22,17 +20,68 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the bot",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		scraper := scraper2.NPRScraper{}
-		broadcaster := &data.Data{}
-		broadcaster.Start(&scraper)
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
 
-		r, err := redis.New(fmt.Sprintf("redis://%s:%d/%d", viper.GetString("redis.host"), viper.GetInt("redis.port"), viper.GetInt("redis.db")))
+		broadcaster := newData()
+
+		s, err := newStore()
 
 		if err != nil {
 			return err
 		}
 
-		tg := telegram.New(viper.GetString("token"), viper.GetString("channel"), r, broadcaster)
+		if r, ok := s.(*redis.Redis); ok {
+			if err := r.EnsureSchema(); err != nil {
+				return err
+			}
+		}
+
+		terminate := make(chan os.Signal, 1)
+		signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			<-terminate
+			cancel()
+		}()
+
+		// Standbys keep scraping so they're warm, but only the leader records and talks to Telegram
+		var lost <-chan struct{}
+		var lease *redis.Lease
+
+		if cfg.HA.Enabled {
+			lease = s.(*redis.Redis).NewLease("leader", holderID(), cfg.HA.LeaseTTL)
+
+			if lost, err = lead(ctx, lease); err != nil {
+				broadcaster.Stop()
+				return nil
+			}
+		}
+
+		recordHistory(s, broadcaster)
+
+		settings := telegram.Settings{
+			CompareYear: cfg.CompareYear,
+			Admins:      cfg.Admins,
+			APIURL:      cfg.APIURL,
+		}
+
+		if cfg.Webhook.Enabled {
+			settings.Webhook = &telegram.WebhookSettings{
+				Listen:     cfg.Webhook.Listen,
+				PublicURL:  cfg.Webhook.PublicURL,
+				SecretPath: cfg.Webhook.SecretPath,
+				TLSCert:    cfg.Webhook.TLSCert,
+				TLSKey:     cfg.Webhook.TLSKey,
+				UploadCert: cfg.Webhook.UploadCert,
+			}
+		}
+
+		tg := telegram.New(cfg.Token, cfg.Channel, s, broadcaster, settings)
 
 		if err := tg.Create(); err != nil {
 			return errors.Wrap(err, "error creating telegram bot")
@@ -40,17 +89,22 @@ var runCmd = &cobra.Command{
 
 		go tg.Start()
 
-		terminate := make(chan os.Signal, 1)
-		signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case <-ctx.Done():
+		case <-lost:
+			// exit so we come back as a standby rather than keep editing next to the new leader
+			err = errors.New("lost the leader lease")
+		}
+
+		tg.Stop()
+		broadcaster.Stop()
 
-		toTerminate := <-terminate
-		for {
-			if toTerminate != nil {
-				tg.Stop()
-				break
+		if lease != nil {
+			if releaseErr := lease.Release(context.Background()); releaseErr != nil {
+				log.WithError(releaseErr).Warn("could not release the leader lease")
 			}
 		}
 
-		return nil
+		return err
 	},
 }
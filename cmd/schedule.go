@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+)
+
+// newSchedule builds the scrape schedule of a source. Every setting can be overridden
+// per source with scrape.sources.<source>.<setting>, and falls back to scrape.<setting>.
+func newSchedule(source string) *data.Schedule {
+	schedule := cfg.Scrape.ScheduleFor(source)
+
+	return &data.Schedule{
+		Interval:   schedule.Interval,
+		Min:        schedule.Min,
+		Max:        schedule.Max,
+		Jitter:     schedule.Jitter,
+		PollCloses: election.GetPollCloses(),
+		FastWindow: schedule.FastWindow,
+	}
+}
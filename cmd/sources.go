@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/scraper"
+)
+
+// newSources builds every configured scraper with its schedule
+func newSources() []data.Source {
+	// the base URL of a local fake wins over the election's sources, which win over NPR's 2020 files
+	published := election.GetSources()
+	statesURL, countyPattern := published.States, published.Counties
+
+	if cfg.Scrape.BaseURL != "" {
+		statesURL = scraper.AllStatesURLAt(cfg.Scrape.BaseURL)
+		countyPattern = scraper.CountyURLPatternAt(cfg.Scrape.BaseURL)
+	}
+
+	if cfg.Counties.URL != "" {
+		countyPattern = cfg.Counties.URL
+	}
+
+	sources := []data.Source{
+		{
+			Name:     "npr",
+			Scraper:  scraper.NewNPRScraper(cfg.Scrape.Timeout, statesURL),
+			Schedule: newSchedule("npr"),
+		},
+	}
+
+	if cfg.Counties.Enabled {
+		sources = append(sources, data.Source{
+			Name:     "counties",
+			Scraper:  scraper.NewNPRCountyScraper(cfg.Scrape.Timeout, countyPattern),
+			Schedule: newSchedule("counties"),
+		})
+	}
+
+	return sources
+}
+
+// newData starts polling every configured source
+func newData() *data.Data {
+	d := data.New(data.Settings{
+		CloseMargin:      cfg.CloseMargin,
+		RecountReporting: cfg.RecountReporting,
+		PercentTolerance: cfg.Validation.PercentTolerance,
+		MaxJump:          cfg.Validation.MaxJump,
+		MinJumpVotes:     cfg.Validation.MinJumpVotes,
+	})
+	d.Start(newSources()...)
+
+	return d
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/redis"
+	"github.com/aaomidi/uselections-2020/store"
+)
+
+// newStore opens the configured storage backend
+func newStore() (store.Store, error) {
+	switch cfg.Store {
+	case "redis":
+		return redis.New(cfg.Redis.URL(), cfg.Redis.Prefix, cfg.BotID())
+	case "file":
+		return store.NewFile(cfg.StorePath)
+	case "memory":
+		return store.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, expected redis, file or memory", cfg.Store)
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+func init() {
+	watchCmd.Flags().String("sort", "name", "Order of the states: name or closeness")
+	watchCmd.Flags().Bool("plain", false, "Print a new table on every update instead of redrawing, the default when stdout isn't a terminal")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the results in the terminal",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sortBy, _ := cmd.Flags().GetString("sort")
+		plain, _ := cmd.Flags().GetBool("plain")
+
+		if sortBy != "name" && sortBy != "closeness" {
+			return fmt.Errorf("--sort %q is not name or closeness", sortBy)
+		}
+
+		if !isTerminal(os.Stdout) {
+			plain = true
+		}
+
+		// the table owns stdout
+		log.SetOutput(os.Stderr)
+
+		broadcaster := newData()
+		defer broadcaster.Stop()
+
+		updates := make(chan data.OutgoingUpdate, 2)
+		broadcaster.RegisterDataReceiver(updates)
+
+		terminate := make(chan os.Signal, 1)
+		signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+		var previous []election.Vote
+		for {
+			select {
+			case <-terminate:
+				return nil
+			case update := <-updates:
+				if update.Quarantined {
+					for _, anomaly := range update.Anomalies {
+						fmt.Printf("held back: %s\n", anomaly)
+					}
+					continue
+				}
+
+				if !plain {
+					// clear the screen and go back to the top left
+					fmt.Print("\033[H\033[2J")
+				}
+
+				var diff []election.StateDiff
+				if previous != nil {
+					diff = election.Diff(previous, update.Votes)
+				}
+
+				renderWatchTable(os.Stdout, update, diff, sortBy)
+				previous = update.Votes
+			}
+		}
+	},
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func renderWatchTable(w io.Writer, update data.OutgoingUpdate, diff []election.StateDiff, sortBy string) {
+	p := message.NewPrinter(language.English)
+
+	deltas := make(map[string]int64, len(diff))
+	for _, state := range diff {
+		deltas[state.State.Abbreviation] = state.Votes()
+	}
+
+	// the results have every state, the table only the watched ones
+	statuses := make([]election.RecountStatus, 0, len(update.Recounts))
+	for _, status := range update.Recounts {
+		if election.StateExists(status.State.Abbreviation) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if sortBy == "closeness" && statuses[i].MarginPercent != statuses[j].MarginPercent {
+			return statuses[i].MarginPercent < statuses[j].MarginPercent
+		}
+
+		return statuses[i].State.Name < statuses[j].State.Name
+	})
+
+	_, _ = p.Fprintf(w, "%s\n\n", time.Now().Format("15:04:05 MST"))
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(table, "State\tLeader\tMargin\tVotes\tReporting\tΔ Votes\t")
+
+	for _, status := range statuses {
+		flag := ""
+		switch {
+		case status.InWindow:
+			flag = " recount"
+		case status.TooClose:
+			flag = " close"
+		}
+
+		_, _ = p.Fprintf(table, "%s\t%s\t%.2f%%%s\t%d\t%.0f%%\t%+d\t\n",
+			status.State.Abbreviation, status.Leader.Candidate.LastName, status.MarginPercent*100, flag,
+			status.Leader.StateVote.TotalVotes, status.Leader.StateVote.ReportingPercentage*100, deltas[status.State.Abbreviation])
+	}
+
+	_ = table.Flush()
+	_, _ = fmt.Fprintln(w)
+}
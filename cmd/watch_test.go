@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	"strings"
+	"testing"
+)
+
+func TestRenderWatchTable(t *testing.T) {
+	status := func(abbreviation string, name string, leader string, margin float64, total int64, reporting float64) election.RecountStatus {
+		state := election.State{Name: name, Abbreviation: abbreviation}
+
+		return election.RecountStatus{
+			State: state,
+			Leader: election.Vote{
+				State:     state,
+				Candidate: election.Candidate{LastName: leader},
+				StateVote: election.StateResults{TotalVotes: total, ReportingPercentage: reporting},
+			},
+			MarginPercent: margin,
+		}
+	}
+
+	pa := status("PA", "Pennsylvania", "Biden", 0.012, 6900000, 0.98)
+	ga := status("GA", "Georgia", "Biden", 0.002, 5000000, 0.99)
+	ga.InWindow = true
+	tx := status("TX", "Texas", "Trump", 0.056, 11300000, 0.97)
+	az := status("AZ", "Arizona", "Biden", 0.004, 3400000, 0.95)
+	az.TooClose = true
+
+	update := data.OutgoingUpdate{
+		Recounts: map[string]election.RecountStatus{"PA": pa, "GA": ga, "TX": tx, "AZ": az},
+	}
+	diff := []election.StateDiff{{State: pa.State, TotalBefore: 6800000, TotalAfter: 6900000}}
+
+	tests := []struct {
+		sortBy string
+		rows   [][]string
+	}{
+		{
+			sortBy: "name",
+			rows: [][]string{
+				{"AZ", "Biden", "0.40%", "close", "3,400,000", "95%", "+0"},
+				{"GA", "Biden", "0.20%", "recount", "5,000,000", "99%", "+0"},
+				{"PA", "Biden", "1.20%", "6,900,000", "98%", "+100,000"},
+			},
+		},
+		{
+			sortBy: "closeness",
+			rows: [][]string{
+				{"GA", "Biden", "0.20%", "recount", "5,000,000", "99%", "+0"},
+				{"AZ", "Biden", "0.40%", "close", "3,400,000", "95%", "+0"},
+				{"PA", "Biden", "1.20%", "6,900,000", "98%", "+100,000"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sortBy, func(t *testing.T) {
+			var out bytes.Buffer
+			renderWatchTable(&out, update, diff, test.sortBy)
+
+			// the first line is the time, then a blank line and the header
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) < 3 {
+				t.Fatalf("no table in\n%s", out.String())
+			}
+
+			if header := strings.Fields(lines[2]); header[0] != "State" {
+				t.Fatalf("expected the header, got %q", lines[2])
+			}
+
+			rows := lines[3:]
+			if len(rows) != len(test.rows) {
+				t.Fatalf("expected %d rows of watched states, got\n%s", len(test.rows), strings.Join(rows, "\n"))
+			}
+
+			for i, row := range rows {
+				if fields := strings.Fields(row); strings.Join(fields, " ") != strings.Join(test.rows[i], " ") {
+					t.Errorf("row %d is %q, expected %q", i, fields, test.rows[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"strings"
+	"time"
+)
+
+// Config is every setting of the bot, merged from the flags, the environment and the config file
+type Config struct {
+	Log    string
+	Colors bool
+
+	Token   string
+	Channel string
+	Admins  []int
+	Webhook Webhook
+
+	// APIURL is the Telegram Bot API server, the public one when empty
+	APIURL string `mapstructure:"api-url"`
+
+	Store     string
+	StorePath string `mapstructure:"store-path"`
+	Redis     Redis
+
+	Scrape   Scrape
+	Counties Counties
+	HA       HA
+
+	Validation Validation
+
+	// Election is the definition file of the election to cover, the built in 2020 presidential election when empty
+	Election string
+
+	CloseMargin float64 `mapstructure:"close-margin"`
+	CompareYear int     `mapstructure:"compare-year"`
+
+	// RecountReporting is how much of a state has to report before it can set off a recount alert
+	RecountReporting float64 `mapstructure:"recount-reporting"`
+
+	// PopulationFile replaces the eligible populations, the election's or the bundled ones
+	PopulationFile string `mapstructure:"population-file"`
+}
+
+type Redis struct {
+	Host string
+	Port int
+	DB   int
+
+	// Prefix namespaces every key, so several deployments can share a database
+	Prefix string
+}
+
+// BotID is the numeric ID of the bot, the part of the token before the colon
+func (c *Config) BotID() string {
+	return strings.SplitN(c.Token, ":", 2)[0]
+}
+
+// URL is the redis:// URL of the configured server
+func (r Redis) URL() string {
+	return fmt.Sprintf("redis://%s:%d/%d", r.Host, r.Port, r.DB)
+}
+
+// Scrape is the default schedule of every source, and the per source overrides
+type Scrape struct {
+	Timeout time.Duration
+
+	// BaseURL serves the NPR data files from another server, NPR's when empty
+	BaseURL string `mapstructure:"base-url"`
+
+	Schedule `mapstructure:",squash"`
+
+	// Sources override parts of the schedule of a single source, keyed by the source's name
+	Sources map[string]Schedule
+}
+
+type Schedule struct {
+	Interval   time.Duration
+	Min        time.Duration
+	Max        time.Duration
+	Jitter     float64
+	FastWindow time.Duration `mapstructure:"fast-window"`
+}
+
+// ScheduleFor is the schedule of a source, the defaults with the source's overrides on top
+func (s Scrape) ScheduleFor(source string) Schedule {
+	schedule := s.Schedule
+	override := s.Sources[source]
+
+	if override.Interval != 0 {
+		schedule.Interval = override.Interval
+	}
+
+	if override.Min != 0 {
+		schedule.Min = override.Min
+	}
+
+	if override.Max != 0 {
+		schedule.Max = override.Max
+	}
+
+	if override.Jitter != 0 {
+		schedule.Jitter = override.Jitter
+	}
+
+	if override.FastWindow != 0 {
+		schedule.FastWindow = override.FastWindow
+	}
+
+	return schedule
+}
+
+// Validation is how implausible a scrape has to look to be held back
+type Validation struct {
+	PercentTolerance float64 `mapstructure:"percent-tolerance"`
+	MaxJump          float64 `mapstructure:"max-jump"`
+	MinJumpVotes     int64   `mapstructure:"min-jump-votes"`
+}
+
+// Webhook has Telegram push updates to a listener of ours instead of us long polling for them
+type Webhook struct {
+	Enabled    bool
+	Listen     string
+	PublicURL  string `mapstructure:"public-url"`
+	SecretPath string `mapstructure:"secret-path"`
+	TLSCert    string `mapstructure:"tls-cert"`
+	TLSKey     string `mapstructure:"tls-key"`
+	UploadCert bool   `mapstructure:"upload-cert"`
+}
+
+// HA runs several replicas of which only the one holding the leader lease talks to Telegram
+type HA struct {
+	Enabled  bool
+	LeaseTTL time.Duration `mapstructure:"lease-ttl"`
+}
+
+type Counties struct {
+	Enabled bool
+	URL     string
+}
+
+// Load reads the configuration out of viper. It only fails when a value can't be parsed, use Validate to check the values.
+func Load() (*Config, error) {
+	c := &Config{}
+
+	if err := viper.Unmarshal(c); err != nil {
+		return nil, NewError(err, "unable to parse configuration")
+	}
+
+	return c, nil
+}
+
+// Validate checks every setting and reports all the problems it found at once
+func (c *Config) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, err := log.ParseLevel(c.Log); err != nil {
+		problem("log: %q is not a log level", c.Log)
+	}
+
+	if c.Token == "" {
+		problem("token: the telegram bot token is required")
+	} else if !strings.Contains(c.Token, ":") {
+		problem("token: does not look like a telegram bot token, expected <bot id>:<secret>")
+	}
+
+	if c.Channel == "" {
+		problem("channel: the telegram channel is required")
+	}
+
+	if c.APIURL != "" && !strings.HasPrefix(c.APIURL, "http://") && !strings.HasPrefix(c.APIURL, "https://") {
+		problem("api-url: %q is not an http(s) URL", c.APIURL)
+	}
+
+	if c.Webhook.Enabled {
+		if c.Webhook.Listen == "" {
+			problem("webhook.listen: the address to listen on is required by the webhook")
+		}
+
+		if !strings.HasPrefix(c.Webhook.PublicURL, "https://") {
+			problem("webhook.public-url: %q has to be an https:// URL, telegram only pushes over https", c.Webhook.PublicURL)
+		}
+
+		if (c.Webhook.TLSCert == "") != (c.Webhook.TLSKey == "") {
+			problem("webhook.tls-cert: the certificate and the key have to be given together")
+		}
+
+		if c.Webhook.UploadCert && c.Webhook.TLSCert == "" {
+			problem("webhook.upload-cert: there is no tls-cert to upload")
+		}
+	}
+
+	switch c.Store {
+	case "redis":
+		if c.Redis.Host == "" {
+			problem("redis.host: required by the redis store")
+		}
+
+		if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
+			problem("redis.port: %d is not a port", c.Redis.Port)
+		}
+
+		if c.Redis.DB < 0 {
+			problem("redis.db: %d can't be negative", c.Redis.DB)
+		}
+
+		if c.Redis.Prefix == "" {
+			problem("redis.prefix: required by the redis store")
+		}
+	case "file":
+		if c.StorePath == "" {
+			problem("store-path: required by the file store")
+		}
+	case "memory":
+	default:
+		problem("store: %q is not a store, expected redis, file or memory", c.Store)
+	}
+
+	if c.Scrape.BaseURL != "" && !strings.HasPrefix(c.Scrape.BaseURL, "http://") && !strings.HasPrefix(c.Scrape.BaseURL, "https://") {
+		problem("scrape.base-url: %q is not an http(s) URL", c.Scrape.BaseURL)
+	}
+
+	if c.Scrape.Timeout <= 0 {
+		problem("scrape.timeout: has to be positive")
+	}
+
+	sources := []string{""}
+	for source := range c.Scrape.Sources {
+		sources = append(sources, source)
+	}
+
+	for _, source := range sources {
+		prefix := "scrape"
+		if source != "" {
+			prefix = "scrape.sources." + source
+		}
+
+		schedule := c.Scrape.ScheduleFor(source)
+
+		if schedule.Min <= 0 {
+			problem("%s.min: has to be positive", prefix)
+		}
+
+		if schedule.Max < schedule.Min {
+			problem("%s.max: %s is shorter than the minimum of %s", prefix, schedule.Max, schedule.Min)
+		}
+
+		if schedule.Jitter < 0 || schedule.Jitter >= 1 {
+			problem("%s.jitter: %v has to be between 0 and 1", prefix, schedule.Jitter)
+		}
+	}
+
+	if c.HA.Enabled {
+		if c.Store != "redis" {
+			problem("ha.enabled: the leader lease needs the redis store, not %q", c.Store)
+		}
+
+		if c.HA.LeaseTTL < 3*time.Second {
+			problem("ha.lease-ttl: %s is too short to renew the lease in time, use at least 3s", c.HA.LeaseTTL)
+		}
+	}
+
+	if c.Validation.PercentTolerance <= 0 {
+		problem("validation.percent-tolerance: has to be positive")
+	}
+
+	if c.Validation.MaxJump <= 0 {
+		problem("validation.max-jump: has to be positive")
+	}
+
+	if c.CloseMargin < 0 || c.CloseMargin >= 1 {
+		problem("close-margin: %v has to be a fraction between 0 and 1", c.CloseMargin)
+	}
+
+	if c.RecountReporting < 0 || c.RecountReporting > 1 {
+		problem("recount-reporting: %v has to be a fraction between 0 and 1", c.RecountReporting)
+	}
+
+	if len(problems) > 0 {
+		return ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// ValidationError lists everything that's wrong with a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e ValidationError) Error() string {
+	return "invalid configuration:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+// Redacted returns the merged settings with the secrets masked, for printing
+func Redacted() map[string]interface{} {
+	settings := viper.AllSettings()
+
+	if token, ok := settings["token"].(string); ok && token != "" {
+		settings["token"] = redact(token)
+	}
+
+	if webhook, ok := settings["webhook"].(map[string]interface{}); ok {
+		if secret, ok := webhook["secret-path"].(string); ok && secret != "" {
+			webhook["secret-path"] = "****"
+		}
+	}
+
+	return settings
+}
+
+// redact keeps the bot ID part of a token so it's still clear which bot is configured
+func redact(token string) string {
+	if i := strings.Index(token, ":"); i >= 0 {
+		return token[:i] + ":****"
+	}
+
+	return "****"
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ElectionsDir is where election definitions are looked up by name
+const ElectionsDir = "elections"
+
+// electionFile is the layout of an election definition file
+type electionFile struct {
+	Name    string
+	Sources election.Sources
+	Offices []string
+	States  map[string]string
+	Parties map[string]election.Party
+	Compare election.ComparedParties
+
+	// Baselines are the votes of the compared parties and the total, keyed by year and state
+	Baselines map[string]map[string]struct {
+		Dem   int64
+		Rep   int64
+		Total int64
+	}
+
+	Candidates []struct {
+		ID        string
+		First     string
+		Last      string
+		Party     string
+		Incumbent bool
+	}
+
+	// PollCloses are RFC 3339 times keyed by state abbreviation
+	PollCloses map[string]string `mapstructure:"poll-closes"`
+
+	// RecountRules are keyed by state abbreviation, margin-percent is a fraction like 0.005 for half a percent
+	RecountRules map[string]struct {
+		Automatic     bool
+		MarginPercent float64 `mapstructure:"margin-percent"`
+		MarginVotes   int64   `mapstructure:"margin-votes"`
+	} `mapstructure:"recount-rules"`
+
+	// Populations are keyed by state abbreviation, their turnout by year
+	Populations map[string]struct {
+		VEP     int64
+		Turnout map[string]float64
+	}
+}
+
+// LoadElection reads an election definition, YAML or JSON. name is a path to the file, or the name of a file in ElectionsDir.
+func LoadElection(name string) (election.Definition, error) {
+	path, err := findElection(name)
+
+	if err != nil {
+		return election.Definition{}, err
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return election.Definition{}, NewError(err, "unable to read election "+path)
+	}
+
+	var file electionFile
+
+	if err := v.Unmarshal(&file); err != nil {
+		return election.Definition{}, NewError(err, "unable to parse election "+path)
+	}
+
+	return file.definition()
+}
+
+// findElection resolves the name of an election to its file
+func findElection(name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		path := filepath.Join(ElectionsDir, name+ext)
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("election %q is neither a file nor in %s/", name, ElectionsDir)
+}
+
+func (f electionFile) definition() (election.Definition, error) {
+	d := election.Definition{
+		Name:       f.Name,
+		Sources:    f.Sources,
+		Offices:    f.Offices,
+		States:     make(map[string]string, len(f.States)),
+		Parties:    make(map[string]election.Party, len(f.Parties)),
+		Compare:    f.Compare,
+		Baselines:  make(map[int]map[string]election.Baseline, len(f.Baselines)),
+		PollCloses: make(map[string]time.Time, len(f.PollCloses)),
+	}
+
+	// viper lowercases every key, the abbreviations are put back in shape here
+	for state, name := range f.States {
+		d.States[strings.ToUpper(state)] = name
+	}
+
+	for key, party := range f.Parties {
+		if party.Abbreviation == "" {
+			return d, fmt.Errorf("parties.%s.abbreviation: required, it's what the source calls the party", key)
+		}
+
+		d.Parties[party.Abbreviation] = party
+	}
+
+	for key, states := range f.Baselines {
+		year, err := strconv.Atoi(key)
+
+		if err != nil {
+			return d, fmt.Errorf("baselines.%s: not a year", key)
+		}
+
+		d.Baselines[year] = make(map[string]election.Baseline, len(states))
+		for state, votes := range states {
+			d.Baselines[year][strings.ToUpper(state)] = election.Baseline{
+				Year:       year,
+				Dem:        votes.Dem,
+				GOP:        votes.Rep,
+				TotalVotes: votes.Total,
+			}
+		}
+	}
+
+	for state, closes := range f.PollCloses {
+		t, err := time.Parse(time.RFC3339, closes)
+
+		if err != nil {
+			return d, fmt.Errorf("poll-closes.%s: %q is not an RFC 3339 time", state, closes)
+		}
+
+		d.PollCloses[strings.ToUpper(state)] = t
+	}
+
+	if len(f.RecountRules) > 0 {
+		d.RecountRules = make(map[string]election.RecountRule, len(f.RecountRules))
+	}
+
+	for state, rule := range f.RecountRules {
+		d.RecountRules[strings.ToUpper(state)] = election.RecountRule{
+			Automatic:     rule.Automatic,
+			MarginPercent: rule.MarginPercent,
+			MarginVotes:   rule.MarginVotes,
+		}
+	}
+
+	if len(f.Populations) > 0 {
+		d.Populations = make(map[string]election.Population, len(f.Populations))
+	}
+
+	for state, population := range f.Populations {
+		p := election.Population{
+			VEP:     population.VEP,
+			Turnout: make(map[int]float64, len(population.Turnout)),
+		}
+
+		for key, turnout := range population.Turnout {
+			year, err := strconv.Atoi(key)
+
+			if err != nil {
+				return d, fmt.Errorf("populations.%s.turnout.%s: not a year", state, key)
+			}
+
+			p.Turnout[year] = turnout
+		}
+
+		d.Populations[strings.ToUpper(state)] = p
+	}
+
+	for _, c := range f.Candidates {
+		var party election.Party
+		for _, p := range d.Parties {
+			if strings.EqualFold(p.Abbreviation, c.Party) {
+				party = p
+			}
+		}
+
+		if c.Party != "" && party.Abbreviation == "" {
+			return d, fmt.Errorf("candidates: the party %q of %s is not one of the parties", c.Party, c.Last)
+		}
+
+		d.Candidates = append(d.Candidates, election.Candidate{
+			ID:        c.ID,
+			FirstName: c.First,
+			LastName:  c.Last,
+			Party:     party,
+			Incumbent: c.Incumbent,
+		})
+	}
+
+	return d, d.Validate()
+}
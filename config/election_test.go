@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"testing"
+)
+
+func TestLoadBundledElection(t *testing.T) {
+	d, err := LoadElection("../elections/2020-president.yaml")
+
+	if err != nil {
+		t.Fatalf("the bundled election doesn't load: %v", err)
+	}
+
+	if rule := d.RecountRules["NC"]; rule != (election.RecountRule{MarginPercent: 0.005, MarginVotes: 10000}) {
+		t.Errorf("NC recounts on %+v, expected within 0.5%% and 10000 votes", rule)
+	}
+
+	if rule := d.RecountRules["MI"]; !rule.Automatic || rule.MarginVotes != 2000 {
+		t.Errorf("MI recounts on %+v, expected automatically within 2000 votes", rule)
+	}
+
+	if len(d.RecountRules) != 6 {
+		t.Errorf("expected the recount rules of 6 states, got %d", len(d.RecountRules))
+	}
+
+	pa, ok := d.Populations["PA"]
+	if !ok || pa.VEP != 9888000 || pa.Turnout[2016] != 0.636 {
+		t.Errorf("PA has the population %+v, expected a VEP of 9888000 and 63.6%% turnout in 2016", pa)
+	}
+}
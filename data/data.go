@@ -2,15 +2,70 @@ package data
 
 import (
 	"context"
+	"errors"
 	"github.com/aaomidi/uselections-2020/election"
 	"github.com/aaomidi/uselections-2020/scraper"
 	log "github.com/sirupsen/logrus"
+	"reflect"
+	"sync"
 	"time"
 )
 
 type Data struct {
 	broadcaster chan<- BroadcastRequest
+	cancel      context.CancelFunc
+	settings    Settings
 	log         *log.Entry
+
+	// refresh wakes up every source for an immediate scrape
+	refresh []chan struct{}
+
+	// accept publishes the quarantined snapshot anyway
+	accept chan struct{}
+
+	statusMu sync.RWMutex
+	statuses []*SourceStatus
+}
+
+// SourceStatus is how the polling of a source is going
+type SourceStatus struct {
+	Name       string
+	LastScrape time.Time
+	LastChange time.Time
+	LastError  error
+	NextScrape time.Time
+}
+
+// Settings tune how the data is analysed before it's broadcast
+type Settings struct {
+	// CloseMargin is the margin, as a fraction of all votes, under which an uncalled state is too close to call
+	CloseMargin float64
+
+	// RecountReporting is the share of precincts a state needs reporting before it can set off a recount alert,
+	// so an early count that happens to be close doesn't. A called state always can.
+	RecountReporting float64
+
+	// PercentTolerance is how far the percentages of a state may add up away from 100%, as a fraction
+	PercentTolerance float64
+
+	// MaxJump is the largest plausible growth of a state's total between two scrapes, as a fraction of the total.
+	// Jumps under MinJumpVotes votes are always plausible, so the first few batches of a state don't trip it.
+	MaxJump      float64
+	MinJumpVotes int64
+}
+
+func New(settings Settings) *Data {
+	return &Data{
+		settings: settings,
+		accept:   make(chan struct{}, 1),
+	}
+}
+
+// Source is a scraper together with how often it should be polled
+type Source struct {
+	Name     string
+	Scraper  scraper.Scraper
+	Schedule *Schedule
 }
 
 type BroadcastRequest struct {
@@ -24,50 +79,252 @@ type OutgoingUpdate struct {
 
 	// NotificationVotes these are the interesting votes that passed a certain threshold and we should tell users about that
 	NotificationVotes []election.Vote
+
+	// Recounts is how close every state is to its recount window, keyed by state abbreviation
+	Recounts map[string]election.RecountStatus
+
+	// CountyDeltas are the counties that reported new votes since the last county scrape, keyed by state abbreviation
+	CountyDeltas map[string][]election.CountyDelta
+
+	// Quarantined is set when the latest scrape looked wrong and wasn't published. Votes is empty then,
+	// listeners should keep showing what they have and tell someone about the Anomalies.
+	Quarantined bool
+	Anomalies   []Anomaly
 }
 
-func (d *Data) Start(s scraper.Scraper) {
+// sourceVotes is a single scrape of a single source
+type sourceVotes struct {
+	source string
+	votes  []election.Vote
+}
+
+func (d *Data) Start(sources ...Source) {
 	broadcaster := make(chan BroadcastRequest)
 	d.broadcaster = broadcaster
 	d.log = log.WithField("source", "data")
 
-	aggregation := make(chan []election.Vote)
-	go func(s scraper.Scraper) {
-		for range time.Tick(5 * time.Second) {
-			d.log.Info("running scraper")
-			votes := make([]election.Vote, 0, 153)
-			for vote := range s.Scrape(context.Background()) {
-				votes = append(votes, vote)
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	aggregation := make(chan sourceVotes)
+	for _, source := range sources {
+		refresh := make(chan struct{}, 1)
+		status := &SourceStatus{Name: source.Name}
+
+		d.refresh = append(d.refresh, refresh)
+		d.statuses = append(d.statuses, status)
+
+		go d.poll(ctx, source, status, refresh, aggregation)
+	}
+
+	go d.aggregate(aggregation, broadcaster)
+}
+
+// Stop stops polling every source
+func (d *Data) Stop() {
+	d.cancel()
+}
+
+// Refresh scrapes every source right away instead of waiting for its schedule
+func (d *Data) Refresh() {
+	for _, refresh := range d.refresh {
+		select {
+		case refresh <- struct{}{}:
+		default:
+			// a refresh is already pending
+		}
+	}
+}
+
+// Status returns a copy of the polling status of every source
+func (d *Data) Status() []SourceStatus {
+	d.statusMu.RLock()
+	defer d.statusMu.RUnlock()
+
+	result := make([]SourceStatus, 0, len(d.statuses))
+	for _, status := range d.statuses {
+		result = append(result, *status)
+	}
+
+	return result
+}
+
+func (d *Data) poll(ctx context.Context, source Source, status *SourceStatus, refresh <-chan struct{}, aggregation chan<- sourceVotes) {
+	logger := d.log.WithField("scraper", source.Name)
+	var last []election.Vote
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-refresh:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+
+		logger.Info("running scraper")
+		votes, err := d.scrape(ctx, source.Scraper)
+
+		changed := err == nil && !reflect.DeepEqual(votes, last)
+
+		switch {
+		case errors.Is(err, scraper.ErrNotModified):
+			logger.Debug("nothing changed upstream")
+			err = nil
+		case err != nil:
+			logger.WithError(err).Warn("scrape failed")
+		case changed:
+			last = votes
+			aggregation <- sourceVotes{
+				source: source.Name,
+				votes:  votes,
 			}
+		}
+
+		wait := source.Schedule.Next(changed, err)
+		logger.Debugf("next scrape in %s", wait)
+		timer.Reset(wait)
 
-			aggregation <- votes
+		d.statusMu.Lock()
+		status.LastScrape = time.Now()
+		status.LastError = err
+		status.NextScrape = status.LastScrape.Add(wait)
+		if changed {
+			status.LastChange = status.LastScrape
 		}
-	}(s)
+		d.statusMu.Unlock()
+	}
+}
 
-	go d.aggregate(aggregation, broadcaster)
+func (d *Data) scrape(ctx context.Context, s scraper.Scraper) ([]election.Vote, error) {
+	results, err := s.Scrape(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]election.Vote, 0, 153)
+	for vote := range results {
+		votes = append(votes, vote)
+	}
+
+	return votes, nil
 }
 
-func (d *Data) aggregate(incoming <-chan []election.Vote, broadcastRequests <-chan BroadcastRequest) {
+func (d *Data) aggregate(incoming <-chan sourceVotes, broadcastRequests <-chan BroadcastRequest) {
 	listeners := make([]BroadcastRequest, 0, 5)
+	latest := make(map[string][]election.Vote)
+	recounts := newRecountTracker(d.settings.CloseMargin, d.settings.RecountReporting)
+	validation := newValidator(d.settings)
+	// counties are the county votes of the last published snapshot, the next county deltas are measured from them
+	var counties []election.Vote
+
+	// quarantined is the last snapshot that failed validation, until a good one or an accept replaces it,
+	// with the county votes that came with it
+	var quarantined, quarantinedCounties []election.Vote
+
+	// last is the latest published update, replayed to listeners that register after it went out
+	var last *OutgoingUpdate
+
+	broadcast := func(update OutgoingUpdate) {
+		for _, listener := range listeners {
+			select {
+			case listener.listenerWritable <- update:
+			default:
+				d.log.Warning("Some listener was full :/")
+			}
+		}
+	}
+
+	publish := func(votes []election.Vote, currentCounties []election.Vote) {
+		var countyDeltas map[string][]election.CountyDelta
+		if counties != nil {
+			countyDeltas = election.CountyDeltas(counties, currentCounties)
+		}
+		counties = currentCounties
+
+		validation.accept(votes)
+		quarantined, quarantinedCounties = nil, nil
+
+		recountStatuses, notifications := recounts.track(votes)
+
+		update := OutgoingUpdate{
+			Votes:             votes,
+			NotificationVotes: notifications,
+			Recounts:          recountStatuses,
+			CountyDeltas:      countyDeltas,
+		}
+		broadcast(update)
+
+		// a late listener gets the results, not the notifications that were already sent
+		update.NotificationVotes = nil
+		last = &update
+	}
+
 	for {
 		select {
 		case newBroadcast := <-broadcastRequests:
 			listeners = append(listeners, newBroadcast)
-		case newVoteBucket := <-incoming:
-			for _, listener := range listeners {
+
+			if last != nil {
 				select {
-				case listener.listenerWritable <- OutgoingUpdate{
-					Votes:             newVoteBucket,
-					NotificationVotes: nil,
-				}:
+				case newBroadcast.listenerWritable <- *last:
 				default:
-					d.log.Warning("Some listener was full :/")
+					d.log.Warning("New listener was full :/")
 				}
 			}
+		case <-d.accept:
+			if quarantined == nil {
+				continue
+			}
+
+			d.log.Warn("publishing the quarantined snapshot anyway")
+			publish(quarantined, quarantinedCounties)
+		case newVoteBucket := <-incoming:
+			latest[newVoteBucket.source] = newVoteBucket.votes
+
+			votes := make([]election.Vote, 0, 153)
+			currentCounties := make([]election.Vote, 0)
+			for _, sourceVotes := range latest {
+				for _, vote := range sourceVotes {
+					if vote.County != nil {
+						currentCounties = append(currentCounties, vote)
+						continue
+					}
+
+					votes = append(votes, vote)
+				}
+			}
+
+			if anomalies := validation.check(votes); len(anomalies) > 0 {
+				d.log.Warnf("quarantined a snapshot with %d anomalies: %v", len(anomalies), anomalies)
+				quarantined, quarantinedCounties = votes, currentCounties
+
+				broadcast(OutgoingUpdate{
+					Quarantined: true,
+					Anomalies:   anomalies,
+				})
+				continue
+			}
+
+			publish(votes, currentCounties)
 		}
 	}
 }
 
+// Accept publishes the snapshot that is currently quarantined, for when the anomaly turns out to be a real correction
+func (d *Data) Accept() {
+	select {
+	case d.accept <- struct{}{}:
+	default:
+	}
+}
+
 func (d *Data) RegisterDataReceiver(writable chan<- OutgoingUpdate) {
 	d.broadcaster <- BroadcastRequest{
 		listenerWritable: writable,
@@ -0,0 +1,47 @@
+package data
+
+import "github.com/aaomidi/uselections-2020/election"
+
+// recountExit is how far outside its recount window, as a multiple of the window, a state has to get before
+// it counts as having left it. A margin wobbling around the edge of the window then only alerts once.
+const recountExit = 1.5
+
+// recountTracker remembers which states were inside their recount window, so we only notify when a state enters it
+type recountTracker struct {
+	closeMargin  float64
+	minReporting float64
+	inWindow     map[string]bool
+}
+
+func newRecountTracker(closeMargin float64, minReporting float64) *recountTracker {
+	return &recountTracker{
+		closeMargin:  closeMargin,
+		minReporting: minReporting,
+		inWindow:     make(map[string]bool),
+	}
+}
+
+// track returns the recount status of every state, and the top two votes of the states that just entered their recount window
+func (r *recountTracker) track(votes []election.Vote) (map[string]election.RecountStatus, []election.Vote) {
+	statuses := election.GetRecountStatuses(votes, r.closeMargin)
+	var notifications []election.Vote
+
+	for state, status := range statuses {
+		switch {
+		case !r.inWindow[state] && status.InWindow && r.settled(status):
+			notifications = append(notifications, status.Leader, status.RunnerUp)
+			r.inWindow[state] = true
+		case r.inWindow[state] && !status.Rule.Widened(recountExit).Within(status.Margin, status.Leader.StateVote.TotalVotes):
+			r.inWindow[state] = false
+		}
+	}
+
+	return statuses, notifications
+}
+
+// settled reports whether enough of a state is in for its margin to mean something
+func (r *recountTracker) settled(status election.RecountStatus) bool {
+	results := status.Leader.StateVote
+
+	return len(results.Winner) > 0 || results.ReportingPercentage >= r.minReporting
+}
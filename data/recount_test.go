@@ -0,0 +1,111 @@
+package data
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"testing"
+)
+
+// snapshot is Pennsylvania, where recounts are automatic within 0.5%
+type snapshot struct {
+	margin    float64 // the leader's lead as a fraction of the votes
+	reporting float64
+	called    bool
+}
+
+func (s snapshot) votes() []election.Vote {
+	const total = 1000000
+	pa := election.State{Name: "Pennsylvania", Abbreviation: "PA"}
+
+	results := election.StateResults{
+		State:               pa,
+		TotalVotes:          total,
+		ReportingPercentage: s.reporting,
+	}
+
+	biden := election.Candidate{LastName: "Biden", Party: election.Party{Abbreviation: "Dem"}}
+	trump := election.Candidate{LastName: "Trump", Party: election.Party{Abbreviation: "GOP"}}
+
+	if s.called {
+		results.Winner = []election.Winner{{Candidate: biden, ElectoralVotes: 20}}
+	}
+
+	lead := int64(s.margin * total)
+
+	return []election.Vote{
+		{State: pa, Candidate: biden, Count: (total + lead) / 2, StateVote: results},
+		{State: pa, Candidate: trump, Count: (total - lead) / 2, StateVote: results},
+	}
+}
+
+func TestRecountTracker(t *testing.T) {
+	tests := []struct {
+		name      string
+		snapshots []snapshot
+		notified  []bool
+	}{
+		{
+			name:      "entering the window",
+			snapshots: []snapshot{{margin: 0.02, reporting: 0.95}, {margin: 0.003, reporting: 0.97}},
+			notified:  []bool{false, true},
+		},
+		{
+			name:      "staying in the window",
+			snapshots: []snapshot{{margin: 0.003, reporting: 0.95}, {margin: 0.002, reporting: 0.96}, {margin: 0.004, reporting: 0.97}},
+			notified:  []bool{true, false, false},
+		},
+		{
+			name: "wobbling at the edge",
+			snapshots: []snapshot{
+				{margin: 0.0045, reporting: 0.95},
+				{margin: 0.006, reporting: 0.96},
+				{margin: 0.0045, reporting: 0.97},
+				{margin: 0.007, reporting: 0.98},
+				{margin: 0.004, reporting: 0.99},
+			},
+			notified: []bool{true, false, false, false, false},
+		},
+		{
+			name: "leaving past the exit and coming back",
+			snapshots: []snapshot{
+				{margin: 0.004, reporting: 0.95},
+				{margin: 0.008, reporting: 0.96},
+				{margin: 0.004, reporting: 0.97},
+			},
+			notified: []bool{true, false, true},
+		},
+		{
+			name:      "too little reporting",
+			snapshots: []snapshot{{margin: 0.001, reporting: 0.3}, {margin: 0.002, reporting: 0.89}, {margin: 0.002, reporting: 0.9}},
+			notified:  []bool{false, false, true},
+		},
+		{
+			name:      "called states are settled",
+			snapshots: []snapshot{{margin: 0.001, reporting: 0.5, called: true}},
+			notified:  []bool{true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := newRecountTracker(0.01, 0.9)
+
+			for i, s := range test.snapshots {
+				_, notifications := tracker.track(s.votes())
+
+				if notified := len(notifications) > 0; notified != test.notified[i] {
+					t.Errorf("snapshot %d at a %.2f%% margin: notified %v, expected %v", i, s.margin*100, notified, test.notified[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecountNotificationIsTheTopTwo(t *testing.T) {
+	tracker := newRecountTracker(0.01, 0.9)
+
+	_, notifications := tracker.track(snapshot{margin: 0.002, reporting: 1}.votes())
+
+	if len(notifications) != 2 || notifications[0].Candidate.LastName != "Biden" || notifications[1].Candidate.LastName != "Trump" {
+		t.Errorf("expected Biden and Trump, got %+v", notifications)
+	}
+}
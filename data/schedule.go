@@ -0,0 +1,84 @@
+package data
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Schedule decides how long to wait between two scrapes of a source.
+// It polls at Min right after polls close and whenever the data changed,
+// and backs off towards Max while nothing changes or upstream errors.
+type Schedule struct {
+	// Interval is the first wait, before we know anything about the source
+	Interval time.Duration
+	Min      time.Duration
+	Max      time.Duration
+
+	// Jitter is the fraction of the wait that is randomised, 0.2 means ±20%
+	Jitter float64
+
+	// PollCloses are the moments new results start pouring in. For FastWindow after each of them we poll at Min.
+	PollCloses []time.Time
+	FastWindow time.Duration
+
+	// Rand is where the jitter comes from, the shared source when nil
+	Rand *rand.Rand
+
+	current time.Duration
+}
+
+// Next reports how long to wait before scraping again, given whether the last scrape changed anything
+// and whether it failed.
+func (s *Schedule) Next(changed bool, err error) time.Duration {
+	if s.current == 0 {
+		s.current = s.Interval
+	}
+
+	switch {
+	case err != nil:
+		s.current *= 2
+	case changed:
+		s.current = s.Min
+	default:
+		s.current += s.current / 2
+	}
+
+	if err == nil && s.inFastWindow(time.Now()) {
+		s.current = s.Min
+	}
+
+	if s.current < s.Min {
+		s.current = s.Min
+	}
+
+	if s.current > s.Max {
+		s.current = s.Max
+	}
+
+	return s.jitter(s.current)
+}
+
+func (s *Schedule) inFastWindow(now time.Time) bool {
+	for _, closing := range s.PollCloses {
+		if !now.Before(closing) && now.Sub(closing) < s.FastWindow {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Schedule) jitter(d time.Duration) time.Duration {
+	if s.Jitter <= 0 {
+		return d
+	}
+
+	spread := float64(d) * s.Jitter
+
+	random := rand.Float64
+	if s.Rand != nil {
+		random = s.Rand.Float64
+	}
+
+	return d + time.Duration(spread*(2*random()-1))
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	failed := errors.New("502 Bad Gateway")
+
+	tests := []struct {
+		name    string
+		current time.Duration
+		changed bool
+		err     error
+		closes  []time.Time
+		want    time.Duration
+	}{
+		{name: "first wait is the interval backed off", want: 45 * time.Second},
+		{name: "change drops to min", current: 40 * time.Second, changed: true, want: 10 * time.Second},
+		{name: "no change backs off by half", current: 20 * time.Second, want: 30 * time.Second},
+		{name: "no change stops at max", current: 100 * time.Second, want: 120 * time.Second},
+		{name: "error doubles", current: 20 * time.Second, err: failed, want: 40 * time.Second},
+		{name: "error stops at max", current: 90 * time.Second, err: failed, want: 120 * time.Second},
+		{
+			name:    "right after polls close it's min",
+			current: 60 * time.Second,
+			closes:  []time.Time{time.Now().Add(-time.Minute)},
+			want:    10 * time.Second,
+		},
+		{
+			name:    "long after polls close it backs off",
+			current: 60 * time.Second,
+			closes:  []time.Time{time.Now().Add(-2 * time.Hour)},
+			want:    90 * time.Second,
+		},
+		{
+			name:    "errors back off even after polls close",
+			current: 20 * time.Second,
+			err:     failed,
+			closes:  []time.Time{time.Now().Add(-time.Minute)},
+			want:    40 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Schedule{
+				Interval:   30 * time.Second,
+				Min:        10 * time.Second,
+				Max:        120 * time.Second,
+				PollCloses: test.closes,
+				FastWindow: time.Hour,
+				current:    test.current,
+			}
+
+			if got := s.Next(test.changed, test.err); got != test.want {
+				t.Errorf("waits %s, expected %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScheduleJitter(t *testing.T) {
+	s := &Schedule{
+		Interval: 40 * time.Second,
+		Min:      40 * time.Second,
+		Max:      40 * time.Second,
+		Jitter:   0.25,
+		Rand:     rand.New(rand.NewSource(1)),
+	}
+
+	low, high := 30*time.Second, 50*time.Second
+	seen := make(map[time.Duration]bool)
+
+	for i := 0; i < 200; i++ {
+		wait := s.Next(true, nil)
+
+		if wait < low || wait > high {
+			t.Fatalf("waits %s, expected between %s and %s", wait, low, high)
+		}
+
+		seen[wait] = true
+	}
+
+	if len(seen) < 100 {
+		t.Errorf("only %d distinct waits out of 200, the jitter isn't spreading them", len(seen))
+	}
+
+	// the same seed gives the same waits
+	again := &Schedule{Interval: s.Interval, Min: s.Min, Max: s.Max, Jitter: s.Jitter, Rand: rand.New(rand.NewSource(1))}
+	s.Rand = rand.New(rand.NewSource(1))
+
+	for i := 0; i < 10; i++ {
+		if a, b := s.Next(true, nil), again.Next(true, nil); a != b {
+			t.Fatalf("seeded schedules diverged: %s and %s", a, b)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"math"
+)
+
+// Anomaly is something implausible in a scrape, that holds it back from being published
+type Anomaly struct {
+	State   string
+	Problem string
+}
+
+func (a Anomaly) String() string {
+	return a.State + ": " + a.Problem
+}
+
+// validator checks every statewide snapshot against itself and the last one that was published
+type validator struct {
+	settings Settings
+	last     map[string]map[string]election.Vote
+}
+
+func newValidator(settings Settings) *validator {
+	return &validator{
+		settings: settings,
+	}
+}
+
+// check returns everything implausible about a snapshot. An empty result means it's fine to publish.
+func (v *validator) check(votes []election.Vote) []Anomaly {
+	var anomalies []Anomaly
+	anomaly := func(state string, format string, args ...interface{}) {
+		anomalies = append(anomalies, Anomaly{State: state, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	current := indexVotes(votes)
+
+	for state, candidates := range current {
+		var percentages float64
+		var results election.StateResults
+
+		for candidate, vote := range candidates {
+			percentages += vote.Percentage
+			results = vote.StateVote
+
+			if previous, ok := v.last[state][candidate]; ok && vote.Count < previous.Count {
+				anomaly(state, "%s went down from %d to %d votes", candidate, previous.Count, vote.Count)
+			}
+		}
+
+		if results.TotalVotes > 0 && math.Abs(percentages-1) > v.settings.PercentTolerance {
+			anomaly(state, "percentages add up to %.2f%%", percentages*100)
+		}
+
+		if results.ReportingCount > results.TotalPrecincts {
+			anomaly(state, "%d of %d precincts reporting", results.ReportingCount, results.TotalPrecincts)
+		}
+
+		if previous := totalVotes(v.last[state]); previous > 0 {
+			added := results.TotalVotes - previous
+
+			if added > v.settings.MinJumpVotes && float64(added) > float64(previous)*v.settings.MaxJump {
+				anomaly(state, "total jumped from %d to %d votes", previous, results.TotalVotes)
+			}
+		}
+	}
+
+	for state := range v.last {
+		if _, ok := current[state]; !ok {
+			anomaly(state, "disappeared from the results")
+		}
+	}
+
+	return anomalies
+}
+
+// accept makes a snapshot the one the next ones are compared against
+func (v *validator) accept(votes []election.Vote) {
+	v.last = indexVotes(votes)
+}
+
+// indexVotes groups votes by state abbreviation and candidate
+func indexVotes(votes []election.Vote) map[string]map[string]election.Vote {
+	index := make(map[string]map[string]election.Vote)
+
+	for _, vote := range votes {
+		state, ok := index[vote.State.Abbreviation]
+		if !ok {
+			state = make(map[string]election.Vote)
+			index[vote.State.Abbreviation] = state
+		}
+
+		state[vote.Candidate.Key()] = vote
+	}
+
+	return index
+}
+
+func totalVotes(candidates map[string]election.Vote) int64 {
+	for _, vote := range candidates {
+		return vote.StateVote.TotalVotes
+	}
+
+	return 0
+}
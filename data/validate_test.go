@@ -0,0 +1,189 @@
+package data
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// count is a statewide count between Biden and Trump, the percentages follow from the votes unless they're set
+type count struct {
+	state      string
+	biden      int64
+	trump      int64
+	reporting  int
+	precincts  int
+	percentage float64 // Biden's percentage, when it shouldn't add up
+}
+
+func (c count) votes() []election.Vote {
+	state := election.State{Name: c.state, Abbreviation: c.state}
+	total := c.biden + c.trump
+
+	results := election.StateResults{
+		State:          state,
+		TotalVotes:     total,
+		ReportingCount: c.reporting,
+		TotalPrecincts: c.precincts,
+	}
+
+	biden := election.Vote{
+		State:     state,
+		Candidate: election.Candidate{LastName: "Biden"},
+		Count:     c.biden,
+		StateVote: results,
+	}
+	trump := election.Vote{
+		State:     state,
+		Candidate: election.Candidate{LastName: "Trump"},
+		Count:     c.trump,
+		StateVote: results,
+	}
+
+	if total > 0 {
+		biden.Percentage = float64(c.biden) / float64(total)
+		trump.Percentage = float64(c.trump) / float64(total)
+	}
+
+	if c.percentage != 0 {
+		biden.Percentage = c.percentage
+	}
+
+	return []election.Vote{biden, trump}
+}
+
+func counts(cs ...count) []election.Vote {
+	var votes []election.Vote
+
+	for _, c := range cs {
+		votes = append(votes, c.votes()...)
+	}
+
+	return votes
+}
+
+func TestValidatorCheck(t *testing.T) {
+	pa := count{state: "PA", biden: 3000000, trump: 2900000, reporting: 9000, precincts: 9100}
+	ga := count{state: "GA", biden: 2400000, trump: 2390000, reporting: 2600, precincts: 2655}
+
+	tests := []struct {
+		name     string
+		previous []election.Vote
+		current  []election.Vote
+		// the anomalies as the state and a part of the problem
+		want []string
+	}{
+		{
+			name:    "a plausible first snapshot",
+			current: counts(pa, ga),
+		},
+		{
+			name:     "counts going up",
+			previous: counts(pa, ga),
+			current:  counts(count{state: "PA", biden: 3010000, trump: 2905000, reporting: 9050, precincts: 9100}, ga),
+		},
+		{
+			name:     "a count going down",
+			previous: counts(pa, ga),
+			current:  counts(count{state: "PA", biden: 3000000, trump: 2890000, reporting: 9000, precincts: 9100}, ga),
+			want:     []string{"PA: Trump went down from 2900000 to 2890000"},
+		},
+		{
+			name:    "percentages off by more than the tolerance",
+			current: counts(count{state: "PA", biden: 3000000, trump: 2900000, reporting: 9000, precincts: 9100, percentage: 0.55}, ga),
+			want:    []string{"PA: percentages add up to 104.15%"},
+		},
+		{
+			name:    "percentages off within the tolerance",
+			current: counts(count{state: "PA", biden: 3000000, trump: 2900000, reporting: 9000, precincts: 9100, percentage: 0.52}, ga),
+		},
+		{
+			name:    "nothing counted has no percentages",
+			current: counts(count{state: "PA", precincts: 9100}, ga),
+		},
+		{
+			name:    "more precincts reporting than there are",
+			current: counts(count{state: "PA", biden: 3000000, trump: 2900000, reporting: 9101, precincts: 9100}, ga),
+			want:    []string{"PA: 9101 of 9100 precincts"},
+		},
+		{
+			name:     "a state disappearing",
+			previous: counts(pa, ga),
+			current:  counts(pa),
+			want:     []string{"GA: disappeared"},
+		},
+		{
+			name:     "a jump over the limit",
+			previous: counts(count{state: "PA", biden: 100000, trump: 100000, reporting: 500, precincts: 9100}, ga),
+			current:  counts(count{state: "PA", biden: 250000, trump: 160000, reporting: 900, precincts: 9100}, ga),
+			want:     []string{"PA: total jumped from 200000 to 410000"},
+		},
+		{
+			name:     "a jump under the minimum votes",
+			previous: counts(count{state: "PA", biden: 10000, trump: 10000, reporting: 50, precincts: 9100}, ga),
+			current:  counts(count{state: "PA", biden: 40000, trump: 30000, reporting: 150, precincts: 9100}, ga),
+		},
+		{
+			name:     "a big batch under the limit",
+			previous: counts(pa, ga),
+			current:  counts(count{state: "PA", biden: 3400000, trump: 3200000, reporting: 9100, precincts: 9100}, ga),
+		},
+		{
+			name:     "several problems at once",
+			previous: counts(pa, ga),
+			current:  counts(count{state: "PA", biden: 2900000, trump: 2900000, reporting: 9200, precincts: 9100}),
+			want: []string{
+				"GA: disappeared",
+				"PA: 9200 of 9100 precincts",
+				"PA: Biden went down from 3000000 to 2900000",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := newValidator(Settings{PercentTolerance: 0.02, MaxJump: 0.5, MinJumpVotes: 50000})
+
+			if test.previous != nil {
+				v.accept(test.previous)
+			}
+
+			var got []string
+			for _, anomaly := range v.check(test.current) {
+				got = append(got, anomaly.String())
+			}
+			sort.Strings(got)
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got anomalies %q, expected %q", got, test.want)
+			}
+
+			for i, want := range test.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("got anomaly %q, expected %q", got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorComparesToTheAcceptedSnapshot(t *testing.T) {
+	v := newValidator(Settings{PercentTolerance: 0.02, MaxJump: 0.5, MinJumpVotes: 50000})
+	v.accept(counts(count{state: "PA", biden: 3000000, trump: 2900000, reporting: 9000, precincts: 9100}))
+
+	// a quarantined snapshot isn't accepted, so the next one is still compared to the last published one
+	lower := counts(count{state: "PA", biden: 2990000, trump: 2900000, reporting: 9000, precincts: 9100})
+
+	for i := 0; i < 2; i++ {
+		if anomalies := v.check(lower); len(anomalies) != 1 {
+			t.Fatalf("check %d found %v, expected the count going down", i, anomalies)
+		}
+	}
+
+	v.accept(lower)
+
+	if anomalies := v.check(lower); len(anomalies) != 0 {
+		t.Errorf("the accepted snapshot has anomalies against itself: %v", anomalies)
+	}
+}
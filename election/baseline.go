@@ -0,0 +1,97 @@
+package election
+
+// Baseline is a past presidential result in a state, reduced to the two major parties
+type Baseline struct {
+	Year       int
+	Dem        int64
+	GOP        int64
+	TotalVotes int64
+}
+
+// Margin is the Democratic lead over the Republican as a fraction of all votes. Negative when the GOP led.
+func (b Baseline) Margin() float64 {
+	if b.TotalVotes == 0 {
+		return 0
+	}
+
+	return float64(b.Dem-b.GOP) / float64(b.TotalVotes)
+}
+
+// baselines are the certified results of previous cycles, per year and state, presidential unless Use replaced them
+var baselines = map[int]map[string]Baseline{
+	2016: {
+		"AZ": {Year: 2016, Dem: 1161167, GOP: 1252401, TotalVotes: 2573165},
+		"GA": {Year: 2016, Dem: 1877963, GOP: 2089104, TotalVotes: 4114732},
+		"ME": {Year: 2016, Dem: 357735, GOP: 335593, TotalVotes: 747927},
+		"MI": {Year: 2016, Dem: 2268839, GOP: 2279543, TotalVotes: 4799284},
+		"NC": {Year: 2016, Dem: 2189316, GOP: 2362631, TotalVotes: 4741564},
+		"NV": {Year: 2016, Dem: 539260, GOP: 512058, TotalVotes: 1125385},
+		"PA": {Year: 2016, Dem: 2926441, GOP: 2970733, TotalVotes: 6165478},
+		"WI": {Year: 2016, Dem: 1382536, GOP: 1405284, TotalVotes: 2976150},
+	},
+	2012: {
+		"AZ": {Year: 2012, Dem: 1025232, GOP: 1233654, TotalVotes: 2299254},
+		"GA": {Year: 2012, Dem: 1773827, GOP: 2078688, TotalVotes: 3900050},
+		"ME": {Year: 2012, Dem: 401306, GOP: 292276, TotalVotes: 713180},
+		"MI": {Year: 2012, Dem: 2564569, GOP: 2115256, TotalVotes: 4730961},
+		"NC": {Year: 2012, Dem: 2178391, GOP: 2270395, TotalVotes: 4505372},
+		"NV": {Year: 2012, Dem: 531373, GOP: 463567, TotalVotes: 1014918},
+		"PA": {Year: 2012, Dem: 2990274, GOP: 2680434, TotalVotes: 5753670},
+		"WI": {Year: 2012, Dem: 1620985, GOP: 1407966, TotalVotes: 3068434},
+	},
+}
+
+// GetBaseline returns the result of a previous cycle in a state
+func GetBaseline(year int, state string) (Baseline, bool) {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	b, ok := baselines[year][state]
+	return b, ok
+}
+
+// Comparison is how the current count of a state stacks up against a previous cycle
+type Comparison struct {
+	Baseline Baseline
+
+	// Margin is the current Democratic lead as a fraction of all votes counted so far
+	Margin float64
+
+	// MarginShift is how far the margin moved since the baseline. Positive is towards the Democrats.
+	MarginShift float64
+
+	// Swing is the conventional two party swing, half the margin shift
+	Swing float64
+
+	// TurnoutChange is the votes counted so far relative to the baseline's total, 0.1 meaning 10% more votes
+	TurnoutChange float64
+}
+
+// Compare compares the current Democratic and Republican votes of a state against a previous cycle
+func Compare(year int, dem Vote, rep Vote) (Comparison, bool) {
+	b, ok := GetBaseline(year, dem.State.Abbreviation)
+
+	if !ok || dem.StateVote.TotalVotes == 0 {
+		return Comparison{}, false
+	}
+
+	margin := Margin(dem, rep)
+	shift := margin - b.Margin()
+
+	return Comparison{
+		Baseline:      b,
+		Margin:        margin,
+		MarginShift:   shift,
+		Swing:         shift / 2,
+		TurnoutChange: float64(dem.StateVote.TotalVotes-b.TotalVotes) / float64(b.TotalVotes),
+	}, true
+}
+
+// HasBaseline reports whether results of that year are bundled
+func HasBaseline(year int) bool {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	_, ok := baselines[year]
+	return ok
+}
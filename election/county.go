@@ -0,0 +1,88 @@
+package election
+
+import "sort"
+
+type County struct {
+	FIPS  string
+	Name  string
+	State State
+}
+
+// CountyDelta is how many votes a county added between two scrapes, and who they went to
+type CountyDelta struct {
+	County      County
+	Votes       int64
+	Leader      Candidate
+	LeaderVotes int64
+}
+
+// LeaderShare is the fraction of the new votes that went to the leader
+func (c CountyDelta) LeaderShare() float64 {
+	if c.Votes == 0 {
+		return 0
+	}
+
+	return float64(c.LeaderVotes) / float64(c.Votes)
+}
+
+// CountyDeltas attributes the votes that appeared between two county level snapshots to the counties that reported them.
+// The result is grouped by state abbreviation, biggest batch first. Counties that didn't add any votes are left out.
+func CountyDeltas(previous []Vote, current []Vote) map[string][]CountyDelta {
+	type countyCandidate struct {
+		fips      string
+		candidate string
+	}
+
+	before := make(map[countyCandidate]int64, len(previous))
+	for _, vote := range previous {
+		if vote.County == nil {
+			continue
+		}
+
+		before[countyCandidate{vote.County.FIPS, vote.Candidate.Key()}] = vote.Count
+	}
+
+	counties := make(map[string]*CountyDelta)
+	order := make([]string, 0)
+
+	for _, vote := range current {
+		if vote.County == nil {
+			continue
+		}
+
+		added := vote.Count - before[countyCandidate{vote.County.FIPS, vote.Candidate.Key()}]
+
+		if added <= 0 {
+			continue
+		}
+
+		delta, ok := counties[vote.County.FIPS]
+		if !ok {
+			delta = &CountyDelta{County: *vote.County}
+			counties[vote.County.FIPS] = delta
+			order = append(order, vote.County.FIPS)
+		}
+
+		delta.Votes += added
+
+		if added > delta.LeaderVotes {
+			delta.Leader = vote.Candidate
+			delta.LeaderVotes = added
+		}
+	}
+
+	result := make(map[string][]CountyDelta)
+	for _, fips := range order {
+		delta := counties[fips]
+		state := delta.County.State.Abbreviation
+		result[state] = append(result[state], *delta)
+	}
+
+	for _, deltas := range result {
+		sort.Slice(deltas, func(i, j int) bool {
+			return deltas[i].Votes > deltas[j].Votes
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,101 @@
+package election
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCountyDeltas(t *testing.T) {
+	pa := State{Name: "Pennsylvania", Abbreviation: "PA"}
+	ga := State{Name: "Georgia", Abbreviation: "GA"}
+
+	philadelphia := &County{FIPS: "42101", Name: "Philadelphia", State: pa}
+	allegheny := &County{FIPS: "42003", Name: "Allegheny", State: pa}
+	erie := &County{FIPS: "42049", Name: "Erie", State: pa}
+	fulton := &County{FIPS: "13121", Name: "Fulton", State: ga}
+
+	biden := Candidate{FirstName: "Joe", LastName: "Biden", Party: Party{Abbreviation: "Dem"}}
+	trump := Candidate{FirstName: "Donald", LastName: "Trump", Party: Party{Abbreviation: "GOP"}}
+
+	vote := func(county *County, candidate Candidate, count int64) Vote {
+		v := Vote{State: county.State, Candidate: candidate, Count: count, County: county}
+		return v
+	}
+
+	previous := []Vote{
+		vote(philadelphia, biden, 100000), vote(philadelphia, trump, 20000),
+		vote(allegheny, biden, 50000), vote(allegheny, trump, 40000),
+		vote(erie, biden, 10000), vote(erie, trump, 10000),
+		vote(fulton, biden, 70000), vote(fulton, trump, 30000),
+		// statewide votes aren't a county's
+		{State: pa, Candidate: biden, Count: 5000000},
+	}
+
+	current := []Vote{
+		vote(philadelphia, biden, 130000), vote(philadelphia, trump, 30000),
+		vote(allegheny, biden, 60000), vote(allegheny, trump, 80000),
+		// a correction taking votes away isn't a batch
+		vote(erie, biden, 9000), vote(erie, trump, 10000),
+		vote(fulton, biden, 70000), vote(fulton, trump, 30000),
+		{State: pa, Candidate: biden, Count: 9000000},
+	}
+
+	deltas := CountyDeltas(previous, current)
+
+	if len(deltas) != 1 {
+		t.Fatalf("expected deltas of only PA, got %v", deltas)
+	}
+
+	got := deltas["PA"]
+	if len(got) != 2 {
+		t.Fatalf("expected Allegheny and Philadelphia, got %+v", got)
+	}
+
+	tests := []struct {
+		county      string
+		votes       int64
+		leader      string
+		leaderVotes int64
+		share       float64
+	}{
+		// biggest batch first
+		{county: "Allegheny", votes: 50000, leader: "Trump", leaderVotes: 40000, share: 0.8},
+		{county: "Philadelphia", votes: 40000, leader: "Biden", leaderVotes: 30000, share: 0.75},
+	}
+
+	for i, test := range tests {
+		delta := got[i]
+
+		if delta.County.Name != test.county || delta.Votes != test.votes {
+			t.Errorf("delta %d is +%d from %s, expected +%d from %s", i, delta.Votes, delta.County.Name, test.votes, test.county)
+		}
+
+		if delta.Leader.LastName != test.leader || delta.LeaderVotes != test.leaderVotes {
+			t.Errorf("%s went %d to %s, expected %d to %s", test.county, delta.LeaderVotes, delta.Leader.LastName, test.leaderVotes, test.leader)
+		}
+
+		if share := delta.LeaderShare(); math.Abs(share-test.share) > 1e-9 {
+			t.Errorf("%s leader share is %v, expected %v", test.county, share, test.share)
+		}
+	}
+}
+
+func TestCountyDeltasWithoutPrevious(t *testing.T) {
+	county := &County{FIPS: "04013", Name: "Maricopa", State: State{Abbreviation: "AZ"}}
+	current := []Vote{
+		{State: county.State, Candidate: Candidate{LastName: "Biden"}, Count: 300, County: county},
+		{State: county.State, Candidate: Candidate{LastName: "Trump"}, Count: 200, County: county},
+	}
+
+	deltas := CountyDeltas(nil, current)["AZ"]
+
+	if len(deltas) != 1 || deltas[0].Votes != 500 || deltas[0].Leader.LastName != "Biden" {
+		t.Errorf("expected every vote to be new, got %+v", deltas)
+	}
+}
+
+func TestCountyDeltaLeaderShareOfNothing(t *testing.T) {
+	if share := (CountyDelta{}).LeaderShare(); share != 0 {
+		t.Errorf("an empty batch has a leader share of %v", share)
+	}
+}
@@ -0,0 +1,307 @@
+package election
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Definition is everything about an election the bot needs to cover it. The 2020 presidential election is built in,
+// Use switches to another one.
+type Definition struct {
+	Name string
+
+	Sources Sources
+
+	// Offices are the races followed, in NPR's codes: "P" president, "S" senate, "G" governor, "H" house.
+	// Messages show a single race per state, so follow one office unless the states of the races don't overlap.
+	Offices []string
+
+	// States are the watched states, their names keyed by abbreviation
+	States map[string]string
+
+	// Parties are keyed by the party abbreviation of the source
+	Parties map[string]Party
+
+	// Compare are the two parties every message puts side by side
+	Compare ComparedParties
+
+	// Baselines are previous results of the followed race, keyed by year and state, for comparing against.
+	// Leave them out when there's nothing comparable, a presidential result says little about a senate race.
+	Baselines map[int]map[string]Baseline
+
+	// Candidates fill in what the source leaves out about a candidate, matched on the ID
+	Candidates []Candidate
+
+	// PollCloses is when the last polls close in each state
+	PollCloses map[string]time.Time
+
+	// RecountRules are the recount laws of the watched states. The 2020 laws are used when there are none.
+	RecountRules map[string]RecountRule
+
+	// Populations are the eligible populations turnout is worked out from, keyed by state.
+	// The 2020 estimates are used when there are none.
+	Populations map[string]Population
+}
+
+// ComparedParties are the abbreviations of the two parties the messages compare. Dem takes the place of the
+// Democrats and Rep of the Republicans in margins, so a positive margin is a lead of Dem.
+type ComparedParties struct {
+	Dem string
+	Rep string
+}
+
+// IsDem reports whether the party is the one compared on the Democratic side
+func (c ComparedParties) IsDem(party Party) bool {
+	return strings.EqualFold(party.Abbreviation, c.Dem)
+}
+
+// IsRep reports whether the party is the one compared on the Republican side
+func (c ComparedParties) IsRep(party Party) bool {
+	return strings.EqualFold(party.Abbreviation, c.Rep)
+}
+
+// Sources are where the results are published, empty ones keep the scraper's defaults
+type Sources struct {
+	// States is the file with the results of every state
+	States string
+
+	// Counties is the per state county file, %s is replaced with the state abbreviation
+	Counties string
+}
+
+var (
+	// definitionMu guards everything Use replaces, here and in the other files of the package
+	definitionMu sync.RWMutex
+
+	name     = "2020 Presidential Election"
+	sources  Sources
+	offices  = []string{"P"}
+	compared = ComparedParties{Dem: "Dem", Rep: "GOP"}
+
+	// candidates are the known candidates, keyed by ID
+	candidates = map[string]Candidate{}
+)
+
+// Validate checks that the definition is complete and consistent
+func (d Definition) Validate() error {
+	var problems []string
+
+	if len(d.Offices) == 0 {
+		problems = append(problems, "offices: at least one office is required")
+	}
+
+	if len(d.States) == 0 {
+		problems = append(problems, "states: at least one state has to be watched")
+	}
+
+	if len(d.Parties) == 0 {
+		problems = append(problems, "parties: at least one party is required")
+	}
+
+	if !hasParty(d.Parties, d.Compare.Dem) {
+		problems = append(problems, fmt.Sprintf("compare.dem: %q is not one of the parties", d.Compare.Dem))
+	}
+
+	if !hasParty(d.Parties, d.Compare.Rep) {
+		problems = append(problems, fmt.Sprintf("compare.rep: %q is not one of the parties", d.Compare.Rep))
+	}
+
+	for year, states := range d.Baselines {
+		for state := range states {
+			if _, ok := d.States[state]; !ok {
+				problems = append(problems, fmt.Sprintf("baselines.%d: %s is not a watched state", year, state))
+			}
+		}
+	}
+
+	for state := range d.PollCloses {
+		if _, ok := d.States[state]; !ok {
+			problems = append(problems, fmt.Sprintf("poll-closes: %s is not a watched state", state))
+		}
+	}
+
+	for state, rule := range d.RecountRules {
+		if _, ok := d.States[state]; !ok {
+			problems = append(problems, fmt.Sprintf("recount-rules: %s is not a watched state", state))
+		}
+
+		if rule.MarginPercent == 0 && rule.MarginVotes == 0 {
+			problems = append(problems, fmt.Sprintf("recount-rules.%s: needs a margin in percent or votes", state))
+		}
+
+		if rule.MarginPercent < 0 || rule.MarginPercent >= 1 || rule.MarginVotes < 0 {
+			problems = append(problems, fmt.Sprintf("recount-rules.%s: margins are a fraction under 1 and a positive number of votes", state))
+		}
+	}
+
+	for state, population := range d.Populations {
+		if population.VEP <= 0 {
+			problems = append(problems, fmt.Sprintf("populations.%s: the VEP has to be positive", state))
+		}
+	}
+
+	for i, candidate := range d.Candidates {
+		if candidate.ID == "" {
+			problems = append(problems, fmt.Sprintf("candidates[%d]: %s has no id", i, candidate.LastName))
+		}
+	}
+
+	if d.Sources.Counties != "" && !strings.Contains(d.Sources.Counties, "%s") {
+		problems = append(problems, "sources.counties: the pattern needs a %s for the state")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid election %q:\n\t%s", d.Name, strings.Join(problems, "\n\t"))
+	}
+
+	return nil
+}
+
+// hasParty reports whether abbreviation is one of the parties
+func hasParty(parties map[string]Party, abbreviation string) bool {
+	if abbreviation == "" {
+		return false
+	}
+
+	for key, party := range parties {
+		if strings.EqualFold(key, abbreviation) || strings.EqualFold(party.Abbreviation, abbreviation) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Use switches the bot to another election
+func Use(d Definition) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	definitionMu.Lock()
+	defer definitionMu.Unlock()
+
+	name = d.Name
+	sources = d.Sources
+	offices = d.Offices
+	compared = d.Compare
+
+	usc = make(map[string]string, len(d.States))
+	for abbreviation, stateName := range d.States {
+		usc[strings.ToUpper(abbreviation)] = stateName
+	}
+
+	partyLookup = make(map[string]Party, len(d.Parties))
+	for abbreviation, party := range d.Parties {
+		if party.Abbreviation == "" {
+			party.Abbreviation = abbreviation
+		}
+
+		partyLookup[strings.ToLower(abbreviation)] = party
+	}
+
+	pollCloses = make(map[string]time.Time, len(d.PollCloses))
+	for state, closes := range d.PollCloses {
+		pollCloses[strings.ToUpper(state)] = closes
+	}
+
+	candidates = make(map[string]Candidate, len(d.Candidates))
+	for _, candidate := range d.Candidates {
+		candidates[candidate.ID] = candidate
+	}
+
+	baselines = make(map[int]map[string]Baseline, len(d.Baselines))
+	for year, states := range d.Baselines {
+		baselines[year] = make(map[string]Baseline, len(states))
+
+		for state, baseline := range states {
+			baseline.Year = year
+			baselines[year][strings.ToUpper(state)] = baseline
+		}
+	}
+
+	recountRules = defaultRecountRules
+	if d.RecountRules != nil {
+		recountRules = make(map[string]RecountRule, len(d.RecountRules))
+		for state, rule := range d.RecountRules {
+			recountRules[strings.ToUpper(state)] = rule
+		}
+	}
+
+	populations = defaultPopulations
+	if d.Populations != nil {
+		populations = make(map[string]Population, len(d.Populations))
+		for state, population := range d.Populations {
+			populations[strings.ToUpper(state)] = population
+		}
+	}
+
+	return nil
+}
+
+// GetName is the name of the election being covered
+func GetName() string {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	return name
+}
+
+// GetSources returns where the election's results are published
+func GetSources() Sources {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	return sources
+}
+
+// GetComparedParties returns the two parties the messages compare
+func GetComparedParties() ComparedParties {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	return compared
+}
+
+// FollowsOffice reports whether the races of an office are covered
+func FollowsOffice(office string) bool {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	for _, o := range offices {
+		if strings.EqualFold(o, office) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CompleteCandidate fills in the names, party and incumbency the election defines for the candidate's ID
+func CompleteCandidate(c Candidate) Candidate {
+	definitionMu.RLock()
+	known, ok := candidates[c.ID]
+	definitionMu.RUnlock()
+
+	if !ok {
+		return c
+	}
+
+	if c.FirstName == "" {
+		c.FirstName = known.FirstName
+	}
+
+	if c.LastName == "" {
+		c.LastName = known.LastName
+	}
+
+	if c.Party.Name == "" {
+		c.Party = known.Party
+	}
+
+	c.Incumbent = c.Incumbent || known.Incumbent
+
+	return c
+}
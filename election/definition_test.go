@@ -0,0 +1,122 @@
+package election
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRecountRulesAndPopulations(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       map[string]RecountRule
+		populations map[string]Population
+		problem     string
+	}{
+		{name: "none", problem: ""},
+		{
+			name:        "watched states",
+			rules:       map[string]RecountRule{"PA": {Automatic: true, MarginPercent: 0.005}},
+			populations: map[string]Population{"PA": {VEP: 9888000}},
+		},
+		{
+			name:    "rule of an unwatched state",
+			rules:   map[string]RecountRule{"TX": {MarginPercent: 0.005}},
+			problem: "recount-rules: TX is not a watched state",
+		},
+		{
+			name:    "rule without a margin",
+			rules:   map[string]RecountRule{"PA": {Automatic: true}},
+			problem: "recount-rules.PA: needs a margin",
+		},
+		{
+			name:    "margin in percent rather than a fraction",
+			rules:   map[string]RecountRule{"PA": {MarginPercent: 5}},
+			problem: "recount-rules.PA: margins are a fraction",
+		},
+		{
+			name:        "population without a VEP",
+			populations: map[string]Population{"PA": {}},
+			problem:     "populations.PA: the VEP has to be positive",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Definition{
+				Name:         "Test",
+				Offices:      []string{"P"},
+				States:       map[string]string{"PA": "Pennsylvania"},
+				Parties:      map[string]Party{"Dem": {Abbreviation: "Dem"}, "GOP": {Abbreviation: "GOP"}},
+				Compare:      ComparedParties{Dem: "Dem", Rep: "GOP"},
+				RecountRules: test.rules,
+				Populations:  test.populations,
+			}
+
+			err := d.Validate()
+
+			switch {
+			case test.problem == "" && err != nil:
+				t.Errorf("expected it to be valid, got %v", err)
+			case test.problem != "" && (err == nil || !strings.Contains(err.Error(), test.problem)):
+				t.Errorf("expected %q, got %v", test.problem, err)
+			}
+		})
+	}
+}
+
+func TestUseFallsBackToTheBundledRules(t *testing.T) {
+	keepDefinition(t)
+
+	d := Definition{
+		Name:         "Test",
+		Offices:      []string{"P"},
+		States:       map[string]string{"PA": "Pennsylvania", "WI": "Wisconsin"},
+		Parties:      map[string]Party{"Dem": {Abbreviation: "Dem"}, "GOP": {Abbreviation: "GOP"}},
+		Compare:      ComparedParties{Dem: "Dem", Rep: "GOP"},
+		RecountRules: map[string]RecountRule{"PA": {MarginVotes: 100}},
+		Populations:  map[string]Population{"pa": {VEP: 1000}},
+	}
+
+	if err := Use(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if rule, ok := GetRecountRule("PA"); !ok || rule.MarginVotes != 100 {
+		t.Errorf("PA recounts on %+v, expected the election's rule", rule)
+	}
+
+	if _, ok := GetRecountRule("WI"); ok {
+		t.Error("WI kept the bundled rule next to the election's rules")
+	}
+
+	if turnout := GetTurnout("PA", 500); turnout != 0.5 {
+		t.Errorf("PA turnout is %.2f, expected it from the election's population", turnout)
+	}
+
+	d.RecountRules, d.Populations = nil, nil
+
+	if err := Use(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if rule, ok := GetRecountRule("WI"); !ok || rule.MarginPercent != 0.01 {
+		t.Errorf("WI recounts on %+v, expected the bundled 2020 rule", rule)
+	}
+
+	if p, ok := GetPopulation("PA"); !ok || p.VEP != 9888000 {
+		t.Errorf("PA has the population %+v, expected the bundled one", p)
+	}
+}
+
+// keepDefinition puts the election back the way it was once the test is done, Use replaces it for the whole package
+func keepDefinition(t *testing.T) {
+	definitionMu.RLock()
+	n, s, o, c, u, p, pc, cs, b, r, pop := name, sources, offices, compared, usc, partyLookup, pollCloses, candidates, baselines, recountRules, populations
+	definitionMu.RUnlock()
+
+	t.Cleanup(func() {
+		definitionMu.Lock()
+		name, sources, offices, compared, usc, partyLookup, pollCloses, candidates, baselines, recountRules, populations = n, s, o, c, u, p, pc, cs, b, r, pop
+		definitionMu.Unlock()
+	})
+}
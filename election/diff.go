@@ -0,0 +1,140 @@
+package election
+
+import "sort"
+
+// CandidateDelta is how a candidate's result in a state changed between two snapshots
+type CandidateDelta struct {
+	Candidate Candidate
+
+	CountBefore      int64
+	CountAfter       int64
+	PercentageBefore float64
+	PercentageAfter  float64
+
+	Added   bool // The candidate wasn't in the first snapshot
+	Removed bool // The candidate isn't in the second snapshot anymore
+}
+
+// Votes is how many votes the candidate gained, negative when votes were taken away
+func (c CandidateDelta) Votes() int64 {
+	return c.CountAfter - c.CountBefore
+}
+
+// StateDiff is how a state's results changed between two snapshots
+type StateDiff struct {
+	State      State
+	Candidates []CandidateDelta
+
+	TotalBefore            int64
+	TotalAfter             int64
+	ReportingBefore        int
+	ReportingAfter         int
+	ReportingPercentBefore float64
+	ReportingPercentAfter  float64
+
+	Added   bool // The state wasn't in the first snapshot
+	Removed bool // The state isn't in the second snapshot anymore
+}
+
+// Votes is how many votes were added to the state
+func (s StateDiff) Votes() int64 {
+	return s.TotalAfter - s.TotalBefore
+}
+
+// Changed reports whether anything at all is different in the state
+func (s StateDiff) Changed() bool {
+	if s.Added || s.Removed || s.TotalBefore != s.TotalAfter || s.ReportingBefore != s.ReportingAfter ||
+		s.ReportingPercentBefore != s.ReportingPercentAfter {
+		return true
+	}
+
+	for _, c := range s.Candidates {
+		if c.Added || c.Removed || c.CountBefore != c.CountAfter || c.PercentageBefore != c.PercentageAfter {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Diff compares two sets of statewide votes. County level votes are ignored.
+// Only the states where something changed are returned, sorted by state name.
+func Diff(before []Vote, after []Vote) []StateDiff {
+	type stateCandidate struct {
+		state     string
+		candidate string
+	}
+
+	states := make(map[string]*StateDiff)
+	candidates := make(map[stateCandidate]*CandidateDelta)
+	order := make(map[string][]stateCandidate)
+
+	get := func(vote Vote) (*StateDiff, *CandidateDelta) {
+		s, ok := states[vote.State.Abbreviation]
+		if !ok {
+			s = &StateDiff{State: vote.State, Added: true, Removed: true}
+			states[vote.State.Abbreviation] = s
+		}
+
+		key := stateCandidate{vote.State.Abbreviation, vote.Candidate.Key()}
+		c, ok := candidates[key]
+		if !ok {
+			c = &CandidateDelta{Candidate: vote.Candidate, Added: true, Removed: true}
+			candidates[key] = c
+			order[key.state] = append(order[key.state], key)
+		}
+
+		return s, c
+	}
+
+	for _, vote := range before {
+		if vote.County != nil {
+			continue
+		}
+
+		s, c := get(vote)
+		s.Added = false
+		s.TotalBefore = vote.StateVote.TotalVotes
+		s.ReportingBefore = vote.StateVote.ReportingCount
+		s.ReportingPercentBefore = vote.StateVote.ReportingPercentage
+
+		c.Added = false
+		c.CountBefore = vote.Count
+		c.PercentageBefore = vote.Percentage
+	}
+
+	for _, vote := range after {
+		if vote.County != nil {
+			continue
+		}
+
+		s, c := get(vote)
+		s.Removed = false
+		s.State = vote.State
+		s.TotalAfter = vote.StateVote.TotalVotes
+		s.ReportingAfter = vote.StateVote.ReportingCount
+		s.ReportingPercentAfter = vote.StateVote.ReportingPercentage
+
+		c.Removed = false
+		c.Candidate = vote.Candidate
+		c.CountAfter = vote.Count
+		c.PercentageAfter = vote.Percentage
+	}
+
+	result := make([]StateDiff, 0, len(states))
+	for abbreviation, s := range states {
+		for _, key := range order[abbreviation] {
+			s.Candidates = append(s.Candidates, *candidates[key])
+		}
+
+		if s.Changed() {
+			result = append(result, *s)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].State.Name < result[j].State.Name
+	})
+
+	return result
+}
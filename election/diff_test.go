@@ -0,0 +1,150 @@
+package election
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	pa := State{Name: "Pennsylvania", Abbreviation: "PA"}
+	ga := State{Name: "Georgia", Abbreviation: "GA"}
+	az := State{Name: "Arizona", Abbreviation: "AZ"}
+	wi := State{Name: "Wisconsin", Abbreviation: "WI"}
+
+	biden := Candidate{LastName: "Biden"}
+	trump := Candidate{LastName: "Trump"}
+	jorgensen := Candidate{LastName: "Jorgensen"}
+
+	vote := func(state State, candidate Candidate, count int64, percentage float64, total int64, reporting int, reportingPercent float64) Vote {
+		return Vote{
+			State:      state,
+			Candidate:  candidate,
+			Count:      count,
+			Percentage: percentage,
+			StateVote: StateResults{
+				State:               state,
+				TotalVotes:          total,
+				ReportingCount:      reporting,
+				ReportingPercentage: reportingPercent,
+			},
+		}
+	}
+
+	before := []Vote{
+		vote(pa, biden, 1000, 0.5, 2000, 10, 0.1),
+		vote(pa, trump, 1000, 0.5, 2000, 10, 0.1),
+		vote(ga, biden, 500, 0.5, 1000, 5, 0.05),
+		vote(ga, trump, 500, 0.5, 1000, 5, 0.05),
+		vote(wi, biden, 300, 0.6, 500, 3, 0.03),
+		vote(wi, trump, 200, 0.4, 500, 3, 0.03),
+		vote(az, biden, 100, 0.5, 200, 1, 0.01),
+		vote(az, trump, 100, 0.5, 200, 1, 0.01),
+		// county votes are left out
+		{State: wi, Candidate: biden, Count: 1, County: &County{FIPS: "55079"}},
+	}
+
+	after := []Vote{
+		// PA counts more votes
+		vote(pa, biden, 1500, 0.6, 2500, 20, 0.2),
+		vote(pa, trump, 1000, 0.4, 2500, 20, 0.2),
+		// GA only reports more precincts
+		vote(ga, biden, 500, 0.5, 1000, 7, 0.07),
+		vote(ga, trump, 500, 0.5, 1000, 7, 0.07),
+		// WI gets a new candidate and loses one
+		vote(wi, biden, 300, 0.55, 540, 3, 0.03),
+		vote(wi, jorgensen, 240, 0.45, 540, 3, 0.03),
+		// AZ didn't change
+		vote(az, biden, 100, 0.5, 200, 1, 0.01),
+		vote(az, trump, 100, 0.5, 200, 1, 0.01),
+		{State: wi, Candidate: biden, Count: 100, County: &County{FIPS: "55079"}},
+	}
+
+	diff := Diff(before, after)
+
+	names := make([]string, 0, len(diff))
+	for _, s := range diff {
+		names = append(names, s.State.Name)
+	}
+
+	if len(diff) != 3 || names[0] != "Georgia" || names[1] != "Pennsylvania" || names[2] != "Wisconsin" {
+		t.Fatalf("expected Georgia, Pennsylvania and Wisconsin in that order, got %v", names)
+	}
+
+	georgia, pennsylvania, wisconsin := diff[0], diff[1], diff[2]
+
+	t.Run("per candidate deltas", func(t *testing.T) {
+		if pennsylvania.Votes() != 500 || pennsylvania.TotalBefore != 2000 || pennsylvania.TotalAfter != 2500 {
+			t.Errorf("PA added %d votes, from %d to %d", pennsylvania.Votes(), pennsylvania.TotalBefore, pennsylvania.TotalAfter)
+		}
+
+		if len(pennsylvania.Candidates) != 2 {
+			t.Fatalf("expected 2 candidates in PA, got %d", len(pennsylvania.Candidates))
+		}
+
+		b, tr := pennsylvania.Candidates[0], pennsylvania.Candidates[1]
+
+		if b.Candidate.LastName != "Biden" || b.Votes() != 500 || b.PercentageBefore != 0.5 || b.PercentageAfter != 0.6 {
+			t.Errorf("unexpected Biden delta %+v", b)
+		}
+
+		if tr.Candidate.LastName != "Trump" || tr.Votes() != 0 || tr.PercentageAfter != 0.4 {
+			t.Errorf("unexpected Trump delta %+v", tr)
+		}
+	})
+
+	t.Run("reporting changes", func(t *testing.T) {
+		if georgia.Votes() != 0 || georgia.ReportingBefore != 5 || georgia.ReportingAfter != 7 {
+			t.Errorf("GA reporting went from %d to %d with %d votes", georgia.ReportingBefore, georgia.ReportingAfter, georgia.Votes())
+		}
+
+		if georgia.ReportingPercentBefore != 0.05 || georgia.ReportingPercentAfter != 0.07 {
+			t.Errorf("GA reporting went from %v to %v", georgia.ReportingPercentBefore, georgia.ReportingPercentAfter)
+		}
+
+		if !georgia.Changed() {
+			t.Error("GA reporting more precincts isn't a change")
+		}
+	})
+
+	t.Run("new and removed candidates", func(t *testing.T) {
+		byName := make(map[string]CandidateDelta)
+		for _, c := range wisconsin.Candidates {
+			byName[c.Candidate.LastName] = c
+		}
+
+		if c := byName["Jorgensen"]; !c.Added || c.Removed || c.CountBefore != 0 || c.CountAfter != 240 {
+			t.Errorf("Jorgensen should be added with 240 votes, got %+v", c)
+		}
+
+		if c := byName["Trump"]; !c.Removed || c.Added || c.CountBefore != 200 || c.CountAfter != 0 {
+			t.Errorf("Trump should be removed after 200 votes, got %+v", c)
+		}
+
+		if c := byName["Biden"]; c.Added || c.Removed || c.Votes() != 0 {
+			t.Errorf("the county votes leaked into Biden's statewide delta %+v", c)
+		}
+	})
+}
+
+func TestDiffAddedAndRemovedStates(t *testing.T) {
+	nv := State{Name: "Nevada", Abbreviation: "NV"}
+	nc := State{Name: "North Carolina", Abbreviation: "NC"}
+
+	before := []Vote{{State: nv, Candidate: Candidate{LastName: "Biden"}, Count: 10, StateVote: StateResults{TotalVotes: 10}}}
+	after := []Vote{{State: nc, Candidate: Candidate{LastName: "Biden"}, Count: 20, StateVote: StateResults{TotalVotes: 20}}}
+
+	diff := Diff(before, after)
+
+	if len(diff) != 2 {
+		t.Fatalf("expected both states, got %d", len(diff))
+	}
+
+	if !diff[0].Removed || diff[0].Added || diff[0].State.Abbreviation != "NV" {
+		t.Errorf("expected Nevada removed, got %+v", diff[0])
+	}
+
+	if !diff[1].Added || diff[1].Removed || diff[1].Votes() != 20 {
+		t.Errorf("expected North Carolina added with 20 votes, got %+v", diff[1])
+	}
+
+	if len(Diff(before, before)) != 0 {
+		t.Error("the same snapshot twice isn't a change")
+	}
+}
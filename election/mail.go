@@ -0,0 +1,126 @@
+package election
+
+import "time"
+
+// InPersonVotes are the votes cast on election day or early in person, everything that didn't come by mail
+func (s StateResults) InPersonVotes() int64 {
+	return s.TotalVotes - s.AbsenteeVotes
+}
+
+// MailShare is the fraction of the state's votes that came by mail
+func (s StateResults) MailShare() float64 {
+	if s.TotalVotes == 0 {
+		return 0
+	}
+
+	return float64(s.AbsenteeVotes) / float64(s.TotalVotes)
+}
+
+// MailShare is the fraction of the candidate's votes that came by mail
+func (v Vote) MailShare() float64 {
+	if v.Count == 0 {
+		return 0
+	}
+
+	return float64(v.AbsenteeCount) / float64(v.Count)
+}
+
+// Margin is the Democratic lead over the Republican as a fraction of all votes counted so far. Negative when the GOP leads.
+func Margin(dem Vote, rep Vote) float64 {
+	if dem.StateVote.TotalVotes == 0 {
+		return 0
+	}
+
+	return float64(dem.Count-rep.Count) / float64(dem.StateVote.TotalVotes)
+}
+
+// BlueShift is how a state's margin moved since its first count, and how much of what was counted since came by mail.
+// Mail ballots leaned Democratic in 2020 and were counted late in many states, pulling the margin blue as they came in.
+type BlueShift struct {
+	Since time.Time
+
+	MarginBefore float64
+	MarginAfter  float64
+
+	AddedVotes int64
+	AddedMail  int64
+}
+
+// Shift is how far the margin moved, positive is towards the Democrats
+func (b BlueShift) Shift() float64 {
+	return b.MarginAfter - b.MarginBefore
+}
+
+// AddedMailShare is the fraction of the votes counted since that came by mail
+func (b BlueShift) AddedMailShare() float64 {
+	if b.AddedVotes == 0 {
+		return 0
+	}
+
+	return float64(b.AddedMail) / float64(b.AddedVotes)
+}
+
+// FirstCount is the earliest statewide count of a state, the point blue shifts are measured from
+type FirstCount struct {
+	Time time.Time
+	Dem  Vote
+	Rep  Vote
+}
+
+// GetFirstCounts finds the first count of every state in the history, which has to be oldest first
+func GetFirstCounts(history []Snapshot) map[string]FirstCount {
+	firsts := make(map[string]FirstCount)
+	parties := GetComparedParties()
+
+	for _, snapshot := range history {
+		current := make(map[string]FirstCount)
+
+		for _, vote := range snapshot.Votes {
+			state := vote.State.Abbreviation
+
+			if vote.County != nil || vote.StateVote.TotalVotes == 0 {
+				continue
+			}
+
+			if _, ok := firsts[state]; ok {
+				continue
+			}
+
+			first := current[state]
+			first.Time = snapshot.Time
+
+			switch {
+			case parties.IsDem(vote.Candidate.Party):
+				first.Dem = vote
+			case parties.IsRep(vote.Candidate.Party):
+				first.Rep = vote
+			}
+
+			current[state] = first
+		}
+
+		for state, first := range current {
+			firsts[state] = first
+		}
+	}
+
+	return firsts
+}
+
+// GetBlueShift measures how the state moved from its first count to the current Democratic and Republican votes.
+// It's false when nothing was counted since.
+func GetBlueShift(first FirstCount, dem Vote, rep Vote) (BlueShift, bool) {
+	added := dem.StateVote.TotalVotes - first.Dem.StateVote.TotalVotes
+
+	if added <= 0 {
+		return BlueShift{}, false
+	}
+
+	return BlueShift{
+		Since:        first.Time,
+		MarginBefore: Margin(first.Dem, first.Rep),
+		MarginAfter:  Margin(dem, rep),
+		AddedVotes:   added,
+		AddedMail:    dem.StateVote.AbsenteeVotes - first.Dem.StateVote.AbsenteeVotes,
+	}, true
+}
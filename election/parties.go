@@ -18,5 +18,8 @@ var partyLookup = map[string]Party{
 }
 
 func GetParty(abbr string) Party {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
 	return partyLookup[strings.ToLower(abbr)]
 }
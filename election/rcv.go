@@ -0,0 +1,123 @@
+package election
+
+import "sort"
+
+// RCVContest is a ranked choice contest, tabulated round by round until a candidate has a majority of the continuing ballots
+type RCVContest struct {
+	Name   string
+	State  State
+	Office string
+	Rounds []RCVRound
+}
+
+// RCVRound is the count of a single round
+type RCVRound struct {
+	Number int
+
+	// Tallies are the votes of every continuing candidate, most first
+	Tallies []RCVTally
+
+	// Exhausted are the ballots without a continuing choice left, over every round so far
+	Exhausted int64
+
+	// Eliminated are the candidates dropped after this round, empty in the final round
+	Eliminated []Candidate
+
+	// Transfers are where the ballots of the eliminated candidates went in the next round
+	Transfers []RCVTransfer
+}
+
+// RCVTally is the votes of a candidate in a round
+type RCVTally struct {
+	Candidate Candidate
+	Votes     int64
+}
+
+// RCVTransfer is ballots moving from an eliminated candidate to their next continuing choice.
+// Exhausted is set instead of To when the ballots had no choice left.
+type RCVTransfer struct {
+	From      Candidate
+	To        Candidate
+	Exhausted bool
+	Votes     int64
+}
+
+// Continuing is the number of ballots still counting towards a candidate in the round
+func (r RCVRound) Continuing() int64 {
+	var total int64
+	for _, tally := range r.Tallies {
+		total += tally.Votes
+	}
+
+	return total
+}
+
+// Share is the fraction of the continuing ballots a tally has in the round
+func (r RCVRound) Share(tally RCVTally) float64 {
+	continuing := r.Continuing()
+
+	if continuing == 0 {
+		return 0
+	}
+
+	return float64(tally.Votes) / float64(continuing)
+}
+
+// SortTallies orders the tallies of every round most votes first
+func (c *RCVContest) SortTallies() {
+	for i := range c.Rounds {
+		tallies := c.Rounds[i].Tallies
+
+		sort.SliceStable(tallies, func(a, b int) bool {
+			return tallies[a].Votes > tallies[b].Votes
+		})
+	}
+}
+
+// FirstRound is the count of the first choices
+func (c RCVContest) FirstRound() (RCVRound, bool) {
+	if len(c.Rounds) == 0 {
+		return RCVRound{}, false
+	}
+
+	return c.Rounds[0], true
+}
+
+// FinalRound is the last round tabulated
+func (c RCVContest) FinalRound() (RCVRound, bool) {
+	if len(c.Rounds) == 0 {
+		return RCVRound{}, false
+	}
+
+	return c.Rounds[len(c.Rounds)-1], true
+}
+
+// Winner is the candidate with a majority of the continuing ballots in the final round.
+// It's false while nobody has one, when the tabulation isn't done yet.
+func (c RCVContest) Winner() (Candidate, bool) {
+	final, ok := c.FinalRound()
+
+	if !ok || len(final.Tallies) == 0 {
+		return Candidate{}, false
+	}
+
+	leader := final.Tallies[0]
+
+	if leader.Votes*2 <= final.Continuing() {
+		return Candidate{}, false
+	}
+
+	return leader.Candidate, true
+}
+
+// Overturned reports whether the winner isn't who led the first choices, the case ranked choice exists for
+func (c RCVContest) Overturned() bool {
+	winner, ok := c.Winner()
+	first, _ := c.FirstRound()
+
+	if !ok || len(first.Tallies) == 0 {
+		return false
+	}
+
+	return first.Tallies[0].Candidate.Key() != winner.Key()
+}
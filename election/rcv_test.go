@@ -0,0 +1,68 @@
+package election
+
+import "testing"
+
+func TestRCVContestWinner(t *testing.T) {
+	golden := Candidate{LastName: "Golden"}
+	poliquin := Candidate{LastName: "Poliquin"}
+	bond := Candidate{LastName: "Bond"}
+
+	round := func(tallies ...RCVTally) RCVRound {
+		return RCVRound{Tallies: tallies}
+	}
+
+	tests := []struct {
+		name       string
+		rounds     []RCVRound
+		winner     string
+		overturned bool
+	}{
+		{name: "nothing tabulated"},
+		{
+			name:   "majority of the first choices",
+			rounds: []RCVRound{round(RCVTally{golden, 600}, RCVTally{poliquin, 300}, RCVTally{bond, 100})},
+			winner: "Golden",
+		},
+		{
+			name:   "no majority yet",
+			rounds: []RCVRound{round(RCVTally{poliquin, 460}, RCVTally{golden, 450}, RCVTally{bond, 90})},
+		},
+		{
+			name:   "a tie isn't a majority",
+			rounds: []RCVRound{round(RCVTally{golden, 500}, RCVTally{poliquin, 500})},
+		},
+		{
+			name: "first choice leader wins after transfers",
+			rounds: []RCVRound{
+				round(RCVTally{poliquin, 480}, RCVTally{golden, 420}, RCVTally{bond, 100}),
+				round(RCVTally{poliquin, 510}, RCVTally{golden, 470}),
+			},
+			winner: "Poliquin",
+		},
+		{
+			name: "transfers overturn the first choices",
+			rounds: []RCVRound{
+				round(RCVTally{poliquin, 134184}, RCVTally{golden, 132013}, RCVTally{bond, 23427}),
+				round(RCVTally{golden, 142440}, RCVTally{poliquin, 138931}),
+			},
+			winner:     "Golden",
+			overturned: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contest := RCVContest{Rounds: test.rounds}
+
+			winner, ok := contest.Winner()
+
+			if ok != (test.winner != "") || winner.LastName != test.winner {
+				t.Errorf("won by %q (%v), expected %q", winner.LastName, ok, test.winner)
+			}
+
+			if overturned := contest.Overturned(); overturned != test.overturned {
+				t.Errorf("overturned is %v, expected %v", overturned, test.overturned)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package election
+
+import "sort"
+
+// RecountRule is when a state recounts a statewide race. A zero threshold is not part of the rule,
+// when both are set the margin has to be within both of them.
+type RecountRule struct {
+	// Automatic recounts happen on their own, the others have to be requested by a candidate
+	Automatic bool
+
+	// MarginPercent is the widest margin between the top two candidates, as a fraction of the votes cast
+	MarginPercent float64
+
+	// MarginVotes is the widest margin between the top two candidates, in votes
+	MarginVotes int64
+}
+
+// Within reports whether a margin between the top two candidates is inside the recount window
+func (r RecountRule) Within(margin int64, total int64) bool {
+	if r.MarginPercent == 0 && r.MarginVotes == 0 {
+		return false
+	}
+
+	if r.MarginVotes != 0 && margin > r.MarginVotes {
+		return false
+	}
+
+	if r.MarginPercent != 0 && (total == 0 || float64(margin)/float64(total) > r.MarginPercent) {
+		return false
+	}
+
+	return true
+}
+
+// Widened is the rule with both thresholds scaled by factor
+func (r RecountRule) Widened(factor float64) RecountRule {
+	r.MarginPercent *= factor
+	r.MarginVotes = int64(float64(r.MarginVotes) * factor)
+
+	return r
+}
+
+// defaultRecountRules are the 2020 recount laws of the watched states, for elections that don't bring their own
+var defaultRecountRules = map[string]RecountRule{
+	"AZ": {Automatic: true, MarginPercent: 0.001},
+	"GA": {MarginPercent: 0.005},
+	"MI": {Automatic: true, MarginVotes: 2000},
+	"NC": {MarginPercent: 0.005, MarginVotes: 10000},
+	"PA": {Automatic: true, MarginPercent: 0.005},
+	"WI": {MarginPercent: 0.01},
+}
+
+// recountRules are the recount laws of the election being covered, keyed by state abbreviation
+var recountRules = defaultRecountRules
+
+// GetRecountRule returns when a state recounts the race, false when it has no rule
+func GetRecountRule(state string) (RecountRule, bool) {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	rule, ok := recountRules[state]
+	return rule, ok
+}
+
+// RecountStatus is how close the top two candidates of a state are
+type RecountStatus struct {
+	State    State
+	Leader   Vote
+	RunnerUp Vote
+
+	// Margin is the leader's lead in votes
+	Margin int64
+
+	// MarginPercent is the leader's lead as a fraction of all votes in the state
+	MarginPercent float64
+
+	Rule     RecountRule
+	HasRule  bool
+	InWindow bool // The margin is inside the state's recount window
+	TooClose bool // The margin is inside the too close to call margin and nobody won the state yet
+}
+
+// GetRecountStatuses works out the recount status of every state with at least two candidates, keyed by state abbreviation.
+// closeMargin is the margin, as a fraction of all votes, under which an uncalled state is too close to call.
+func GetRecountStatuses(votes []Vote, closeMargin float64) map[string]RecountStatus {
+	byState := make(map[string][]Vote)
+	for _, vote := range votes {
+		byState[vote.State.Abbreviation] = append(byState[vote.State.Abbreviation], vote)
+	}
+
+	result := make(map[string]RecountStatus, len(byState))
+	for state, stateVotes := range byState {
+		if len(stateVotes) < 2 {
+			continue
+		}
+
+		sort.Slice(stateVotes, func(i, j int) bool {
+			return stateVotes[i].Count > stateVotes[j].Count
+		})
+
+		leader, runnerUp := stateVotes[0], stateVotes[1]
+		total := leader.StateVote.TotalVotes
+
+		status := RecountStatus{
+			State:    leader.State,
+			Leader:   leader,
+			RunnerUp: runnerUp,
+			Margin:   leader.Count - runnerUp.Count,
+		}
+
+		if total > 0 {
+			status.MarginPercent = float64(status.Margin) / float64(total)
+		}
+
+		status.Rule, status.HasRule = GetRecountRule(state)
+		status.InWindow = total > 0 && status.Rule.Within(status.Margin, total)
+		status.TooClose = total > 0 && len(leader.StateVote.Winner) == 0 && status.MarginPercent < closeMargin
+
+		result[state] = status
+	}
+
+	return result
+}
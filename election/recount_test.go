@@ -0,0 +1,51 @@
+package election
+
+import "testing"
+
+func TestRecountRuleWithin(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   RecountRule
+		margin int64
+		total  int64
+		want   bool
+	}{
+		{name: "no thresholds", rule: RecountRule{}, margin: 1, total: 1000, want: false},
+		{name: "inside the percentage", rule: RecountRule{MarginPercent: 0.005}, margin: 4999, total: 1000000, want: true},
+		{name: "on the percentage", rule: RecountRule{MarginPercent: 0.005}, margin: 5000, total: 1000000, want: true},
+		{name: "outside the percentage", rule: RecountRule{MarginPercent: 0.005}, margin: 5001, total: 1000000, want: false},
+		{name: "nothing counted", rule: RecountRule{MarginPercent: 0.005}, margin: 0, total: 0, want: false},
+		{name: "inside the votes", rule: RecountRule{MarginVotes: 2000}, margin: 2000, total: 5000000, want: true},
+		{name: "outside the votes", rule: RecountRule{MarginVotes: 2000}, margin: 2001, total: 5000000, want: false},
+		{name: "inside both", rule: RecountRule{MarginPercent: 0.005, MarginVotes: 10000}, margin: 9000, total: 5000000, want: true},
+		{name: "inside the percentage only", rule: RecountRule{MarginPercent: 0.005, MarginVotes: 10000}, margin: 20000, total: 5000000, want: false},
+		{name: "inside the votes only", rule: RecountRule{MarginPercent: 0.005, MarginVotes: 10000}, margin: 9000, total: 1000000, want: false},
+	}
+
+	for _, test := range tests {
+		if got := test.rule.Within(test.margin, test.total); got != test.want {
+			t.Errorf("%s: Within(%d, %d) = %v, expected %v", test.name, test.margin, test.total, got, test.want)
+		}
+	}
+}
+
+func TestRecountRuleWidened(t *testing.T) {
+	rule := RecountRule{Automatic: true, MarginPercent: 0.005, MarginVotes: 10000}
+	wide := rule.Widened(1.5)
+
+	if wide.MarginPercent != 0.0075 || wide.MarginVotes != 15000 || !wide.Automatic {
+		t.Errorf("widened to %+v", wide)
+	}
+
+	if rule.MarginPercent != 0.005 || rule.MarginVotes != 10000 {
+		t.Errorf("widening changed the rule to %+v", rule)
+	}
+
+	if !wide.Within(7000, 1000000) || rule.Within(7000, 1000000) {
+		t.Error("a 0.7% margin should only be inside the widened window")
+	}
+
+	if (RecountRule{}).Widened(2).Within(1, 1000) {
+		t.Error("widening a rule without thresholds made it match")
+	}
+}
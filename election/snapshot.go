@@ -0,0 +1,9 @@
+package election
+
+import "time"
+
+// Snapshot is every vote of a single scrape, as it was broadcast
+type Snapshot struct {
+	Time  time.Time
+	Votes []Vote
+}
@@ -1,6 +1,9 @@
 package election
 
-import "sort"
+import (
+	"sort"
+	"strings"
+)
 
 var usc = map[string]string{
 	"AZ": "Arizona",
@@ -14,6 +17,9 @@ var usc = map[string]string{
 }
 
 func StateExists(code string) bool {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
 	_, ok := usc[code]
 	return ok
 }
@@ -34,6 +40,9 @@ func GetStates() []State {
 }
 
 func GetIndexStates() map[string]State {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
 	result := make(map[string]State)
 
 	for key, value := range usc {
@@ -45,3 +54,61 @@ func GetIndexStates() map[string]State {
 
 	return result
 }
+
+// SearchStates finds the watched states matching a free form query, best match first.
+// An abbreviation matches exactly, names match on a prefix of the name or of any of its words, or anywhere as a last resort.
+func SearchStates(query string) []State {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	if query == "" {
+		return GetStates()
+	}
+
+	type match struct {
+		state State
+		rank  int
+	}
+
+	matches := make([]match, 0)
+	for _, state := range GetStates() {
+		name := strings.ToLower(state.Name)
+		rank := -1
+
+		switch {
+		case strings.ToLower(state.Abbreviation) == query:
+			rank = 0
+		case strings.HasPrefix(name, query):
+			rank = 1
+		case hasWordPrefix(name, query):
+			rank = 2
+		case strings.Contains(name, query):
+			rank = 3
+		}
+
+		if rank >= 0 {
+			matches = append(matches, match{state, rank})
+		}
+	}
+
+	// GetStates is sorted by name, so a stable sort keeps equally good matches alphabetical
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank < matches[j].rank
+	})
+
+	result := make([]State, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.state)
+	}
+
+	return result
+}
+
+func hasWordPrefix(name string, prefix string) bool {
+	for _, word := range strings.Fields(name) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
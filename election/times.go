@@ -0,0 +1,46 @@
+package election
+
+import (
+	"sort"
+	"time"
+)
+
+// pollCloses is when the last polls close in each state, for election night 2020 unless Use replaced them
+var pollCloses = map[string]time.Time{
+	"GA": time.Date(2020, time.November, 4, 0, 0, 0, 0, time.UTC),
+	"NC": time.Date(2020, time.November, 4, 0, 30, 0, 0, time.UTC),
+	"ME": time.Date(2020, time.November, 4, 1, 0, 0, 0, time.UTC),
+	"PA": time.Date(2020, time.November, 4, 1, 0, 0, 0, time.UTC),
+	"AZ": time.Date(2020, time.November, 4, 2, 0, 0, 0, time.UTC),
+	"MI": time.Date(2020, time.November, 4, 2, 0, 0, 0, time.UTC),
+	"WI": time.Date(2020, time.November, 4, 2, 0, 0, 0, time.UTC),
+	"NV": time.Date(2020, time.November, 4, 3, 0, 0, 0, time.UTC),
+}
+
+// GetPollCloses returns the poll closing times of every watched state, earliest first
+func GetPollCloses() []time.Time {
+	states := GetStates()
+
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	seen := make(map[time.Time]bool)
+	result := make([]time.Time, 0, len(pollCloses))
+
+	for _, s := range states {
+		t, ok := pollCloses[s.Abbreviation]
+
+		if !ok || seen[t] {
+			continue
+		}
+
+		seen[t] = true
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+
+	return result
+}
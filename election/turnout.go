@@ -0,0 +1,151 @@
+package election
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Population is how many people can vote in a state, and how many of them did in previous cycles
+type Population struct {
+	// VEP is the voting eligible population, citizens of age who aren't disenfranchised
+	VEP int64 `json:"vep"`
+
+	// Turnout is the share of the VEP that voted for president, keyed by year
+	Turnout map[int]float64 `json:"turnout,omitempty"`
+}
+
+// PopulationFile is the layout of a population dataset on disk
+type PopulationFile struct {
+	Year   int                   `json:"year"`
+	Source string                `json:"source"`
+	States map[string]Population `json:"states"`
+}
+
+// defaultPopulations are the 2020 VEP estimates of the US Elections Project, with the past turnout of the watched states,
+// for elections that don't bring their own
+var defaultPopulations = map[string]Population{
+	"AL": {VEP: 3717000},
+	"AK": {VEP: 526000},
+	"AZ": {VEP: 5189000, Turnout: map[int]float64{2016: 0.561, 2012: 0.533}},
+	"AR": {VEP: 2182000},
+	"CA": {VEP: 25962000},
+	"CO": {VEP: 4299000},
+	"CT": {VEP: 2562000},
+	"DE": {VEP: 730000},
+	"DC": {VEP: 533000},
+	"FL": {VEP: 15551000},
+	"GA": {VEP: 7496000, Turnout: map[int]float64{2016: 0.593, 2012: 0.590}},
+	"HI": {VEP: 1031000},
+	"ID": {VEP: 1296000},
+	"IL": {VEP: 9027000},
+	"IN": {VEP: 5036000},
+	"IA": {VEP: 2373000},
+	"KS": {VEP: 2098000},
+	"KY": {VEP: 3413000},
+	"LA": {VEP: 3463000},
+	"ME": {VEP: 1093000, Turnout: map[int]float64{2016: 0.728, 2012: 0.693}},
+	"MD": {VEP: 4343000},
+	"MA": {VEP: 5111000},
+	"MI": {VEP: 7550000, Turnout: map[int]float64{2016: 0.657, 2012: 0.647}},
+	"MN": {VEP: 4118000},
+	"MS": {VEP: 2237000},
+	"MO": {VEP: 4682000},
+	"MT": {VEP: 849000},
+	"NE": {VEP: 1420000},
+	"NV": {VEP: 2152000, Turnout: map[int]float64{2016: 0.573, 2012: 0.571}},
+	"NH": {VEP: 1084000},
+	"NJ": {VEP: 6255000},
+	"NM": {VEP: 1525000},
+	"NY": {VEP: 13997000},
+	"NC": {VEP: 7759000, Turnout: map[int]float64{2016: 0.648, 2012: 0.646}},
+	"ND": {VEP: 573000},
+	"OH": {VEP: 8890000},
+	"OK": {VEP: 2890000},
+	"OR": {VEP: 3199000},
+	"PA": {VEP: 9888000, Turnout: map[int]float64{2016: 0.636, 2012: 0.595}},
+	"RI": {VEP: 803000},
+	"SC": {VEP: 3849000},
+	"SD": {VEP: 659000},
+	"TN": {VEP: 5191000},
+	"TX": {VEP: 18784000},
+	"UT": {VEP: 2264000},
+	"VT": {VEP: 507000},
+	"VA": {VEP: 6103000},
+	"WA": {VEP: 5539000},
+	"WV": {VEP: 1400000},
+	"WI": {VEP: 4440000, Turnout: map[int]float64{2016: 0.694, 2012: 0.729}},
+	"WY": {VEP: 431000},
+}
+
+// populations are the eligible population of every state in the election being covered
+var populations = defaultPopulations
+
+// LoadPopulations replaces the election's dataset with a PopulationFile in JSON
+func LoadPopulations(path string) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	var file PopulationFile
+
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
+		return fmt.Errorf("%s is not a population file: %w", path, err)
+	}
+
+	if len(file.States) == 0 {
+		return fmt.Errorf("%s has no states", path)
+	}
+
+	loaded := make(map[string]Population, len(file.States))
+	for state, population := range file.States {
+		if population.VEP <= 0 {
+			return fmt.Errorf("%s: the VEP of %s has to be positive", path, state)
+		}
+
+		loaded[strings.ToUpper(state)] = population
+	}
+
+	definitionMu.Lock()
+	populations = loaded
+	definitionMu.Unlock()
+
+	return nil
+}
+
+// GetPopulation returns the eligible population of a state
+func GetPopulation(state string) (Population, bool) {
+	definitionMu.RLock()
+	defer definitionMu.RUnlock()
+
+	p, ok := populations[strings.ToUpper(state)]
+	return p, ok
+}
+
+// GetTurnout is the share of the state's eligible population that cast the votes counted so far, 0 when the population isn't known
+func GetTurnout(state string, totalVotes int64) float64 {
+	p, ok := GetPopulation(state)
+
+	if !ok || p.VEP == 0 {
+		return 0
+	}
+
+	return float64(totalVotes) / float64(p.VEP)
+}
+
+// GetHistoricalTurnout returns the turnout of a state in a previous cycle
+func GetHistoricalTurnout(year int, state string) (float64, bool) {
+	p, ok := GetPopulation(state)
+
+	if !ok {
+		return 0, false
+	}
+
+	turnout, ok := p.Turnout[year]
+	return turnout, ok
+}
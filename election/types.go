@@ -1,9 +1,23 @@
 package election
 
+import "time"
+
 type Candidate struct {
+	// ID is the source's stable ID of the candidate, the same in every state
+	ID        string
 	FirstName string
 	LastName  string
 	Party     Party
+	Incumbent bool
+}
+
+// Key identifies the candidate across states and snapshots. It's the ID, or the last name for data recorded without IDs.
+func (c Candidate) Key() string {
+	if c.ID != "" {
+		return c.ID
+	}
+
+	return c.LastName
 }
 
 type Party struct {
@@ -27,20 +41,25 @@ type Vote struct {
 	Candidate      Candidate
 	State          State
 	Count          int64
+	AbsenteeCount  int64 // The part of Count that was cast absentee or by mail
 	Percentage     float64
 	ElectoralVotes int
 	StateVote      StateResults // Link to the information about the entire state
+	County         *County      // Set when this is a county level vote, nil for statewide votes
 }
 
 // StateResults is the representation of the state of voting in a given state
 type StateResults struct {
+	RaceID              string // The source's stable ID of the race
 	State               State
 	TotalVotes          int64
-	TurnoutPercentage   float64
+	AbsenteeVotes       int64   // The part of TotalVotes that was cast absentee or by mail
+	TurnoutPercentage   float64 // The share of the voting eligible population that cast the votes counted so far
 	ReportingPercentage float64
 	ReportingCount      int
 	TotalPrecincts      int
 	Winner              []Winner
+	Updated             time.Time // When the source last updated the results of this state
 }
 
 // Winner represents a candidate and their electoral votes
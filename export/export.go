@@ -0,0 +1,279 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/store"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row is a single candidate in a single state at a single point in time
+type Row struct {
+	Time                time.Time `json:"time"`
+	Updated             time.Time `json:"updated"`
+	State               string    `json:"state"`
+	StateName           string    `json:"state_name"`
+	CandidateID         string    `json:"candidate_id"`
+	FirstName           string    `json:"first_name"`
+	LastName            string    `json:"last_name"`
+	Party               string    `json:"party"`
+	Incumbent           bool      `json:"incumbent"`
+	Votes               int64     `json:"votes"`
+	AbsenteeVotes       int64     `json:"absentee_votes"`
+	Percentage          float64   `json:"percentage"`
+	ElectoralVotes      int       `json:"electoral_votes"`
+	TotalVotes          int64     `json:"total_votes"`
+	ReportingCount      int       `json:"reporting_count"`
+	TotalPrecincts      int       `json:"total_precincts"`
+	ReportingPercentage float64   `json:"reporting_percentage"`
+	TurnoutPercentage   float64   `json:"turnout_percentage"`
+}
+
+// Filter narrows down what gets exported. Empty fields don't filter anything.
+type Filter struct {
+	States []string
+	From   time.Time
+	To     time.Time
+}
+
+func (f Filter) matches(snapshot election.Snapshot, vote election.Vote) bool {
+	if !store.InRange(snapshot.Time, f.From, f.To) {
+		return false
+	}
+
+	if len(f.States) == 0 {
+		return true
+	}
+
+	for _, state := range f.States {
+		if strings.EqualFold(state, vote.State.Abbreviation) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Snapshots narrows snapshots down to the votes the filter matches, leaving out snapshots with none of them
+func Snapshots(snapshots []election.Snapshot, filter Filter) []election.Snapshot {
+	result := make([]election.Snapshot, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		votes := make([]election.Vote, 0, len(snapshot.Votes))
+
+		for _, vote := range snapshot.Votes {
+			if filter.matches(snapshot, vote) {
+				votes = append(votes, vote)
+			}
+		}
+
+		if len(votes) == 0 {
+			continue
+		}
+
+		snapshot.Votes = votes
+		result = append(result, snapshot)
+	}
+
+	return result
+}
+
+// Rows flattens snapshots into rows, in snapshot order
+func Rows(snapshots []election.Snapshot, filter Filter) []Row {
+	rows := make([]Row, 0)
+
+	for _, snapshot := range snapshots {
+		for _, vote := range snapshot.Votes {
+			if !filter.matches(snapshot, vote) {
+				continue
+			}
+
+			rows = append(rows, Row{
+				Time:                snapshot.Time,
+				Updated:             vote.StateVote.Updated,
+				State:               vote.State.Abbreviation,
+				StateName:           vote.State.Name,
+				CandidateID:         vote.Candidate.ID,
+				FirstName:           vote.Candidate.FirstName,
+				LastName:            vote.Candidate.LastName,
+				Party:               vote.Candidate.Party.Abbreviation,
+				Incumbent:           vote.Candidate.Incumbent,
+				Votes:               vote.Count,
+				AbsenteeVotes:       vote.AbsenteeCount,
+				Percentage:          vote.Percentage,
+				ElectoralVotes:      vote.ElectoralVotes,
+				TotalVotes:          vote.StateVote.TotalVotes,
+				ReportingCount:      vote.StateVote.ReportingCount,
+				TotalPrecincts:      vote.StateVote.TotalPrecincts,
+				ReportingPercentage: vote.StateVote.ReportingPercentage,
+				TurnoutPercentage:   vote.StateVote.TurnoutPercentage,
+			})
+		}
+	}
+
+	return rows
+}
+
+// column is how a single field of a row is named and written in the tabular formats
+type column struct {
+	name  string
+	value func(r Row) interface{}
+}
+
+var columns = []column{
+	{"time", func(r Row) interface{} { return r.Time }},
+	{"updated", func(r Row) interface{} { return r.Updated }},
+	{"state", func(r Row) interface{} { return r.State }},
+	{"state_name", func(r Row) interface{} { return r.StateName }},
+	{"candidate_id", func(r Row) interface{} { return r.CandidateID }},
+	{"first_name", func(r Row) interface{} { return r.FirstName }},
+	{"last_name", func(r Row) interface{} { return r.LastName }},
+	{"party", func(r Row) interface{} { return r.Party }},
+	{"incumbent", func(r Row) interface{} { return r.Incumbent }},
+	{"votes", func(r Row) interface{} { return r.Votes }},
+	{"absentee_votes", func(r Row) interface{} { return r.AbsenteeVotes }},
+	{"percentage", func(r Row) interface{} { return r.Percentage }},
+	{"electoral_votes", func(r Row) interface{} { return r.ElectoralVotes }},
+	{"total_votes", func(r Row) interface{} { return r.TotalVotes }},
+	{"reporting_count", func(r Row) interface{} { return r.ReportingCount }},
+	{"total_precincts", func(r Row) interface{} { return r.TotalPrecincts }},
+	{"reporting_percentage", func(r Row) interface{} { return r.ReportingPercentage }},
+	{"turnout_percentage", func(r Row) interface{} { return r.TurnoutPercentage }},
+}
+
+// Formats are the formats Write understands, plus "snapshot" for WriteSnapshots
+var Formats = []string{"csv", "json", "columnar", "snapshot"}
+
+// IsFormat reports whether format is one of Formats
+func IsFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes rows in one of Formats
+func Write(w io.Writer, format string, rows []Row) error {
+	switch format {
+	case "csv":
+		return WriteCSV(w, rows)
+	case "json":
+		return WriteJSON(w, rows)
+	case "columnar":
+		return WriteColumnar(w, rows)
+	default:
+		return fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// WriteSnapshots writes the snapshots as they are, one JSON object per line. The diff command reads these back.
+func WriteSnapshots(w io.Writer, snapshots []election.Snapshot) error {
+	encoder := json.NewEncoder(w)
+
+	for _, snapshot := range snapshots {
+		if err := encoder.Encode(snapshot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadSnapshots reads back what WriteSnapshots wrote
+func ReadSnapshots(r io.Reader) ([]election.Snapshot, error) {
+	decoder := json.NewDecoder(r)
+	snapshots := make([]election.Snapshot, 0)
+
+	for decoder.More() {
+		var snapshot election.Snapshot
+
+		if err := decoder.Decode(&snapshot); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// WriteCSV writes a header line followed by a line per row
+func WriteCSV(w io.Writer, rows []Row) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		header = append(header, c.name)
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, 0, len(columns))
+		for _, c := range columns {
+			record = append(record, formatCell(c.value(row)))
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteJSON writes newline delimited JSON, an object per row
+func WriteJSON(w io.Writer, rows []Row) error {
+	encoder := json.NewEncoder(w)
+
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteColumnar writes a single JSON object holding an array per column, the layout columnar tools load the fastest
+func WriteColumnar(w io.Writer, rows []Row) error {
+	table := make(map[string][]interface{}, len(columns))
+
+	for _, c := range columns {
+		values := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, c.value(row))
+		}
+
+		table[c.name] = values
+	}
+
+	return json.NewEncoder(w).Encode(table)
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppendAudit adds an entry to the audit stream. Stream IDs start with the time redis added them, which is what GetAudit's range queries.
+func (r *Redis) AppendAudit(entry store.AuditEntry) error {
+	raw, err := json.Marshal(entry)
+
+	if err != nil {
+		return NewError(err, "Could not encode audit entry")
+	}
+
+	err = r.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: r.keys.audit(),
+		ID:     "*",
+		Values: map[string]interface{}{
+			"state": strings.ToUpper(entry.State),
+			"entry": raw,
+		},
+	}).Err()
+
+	if err != nil {
+		return NewError(err, "Could not append audit entry")
+	}
+
+	return nil
+}
+
+func (r *Redis) GetAudit(state string, from time.Time, to time.Time) ([]store.AuditEntry, error) {
+	start, stop := "-", "+"
+
+	if !from.IsZero() {
+		start = strconv.FormatInt(toMillis(from), 10)
+	}
+
+	if !to.IsZero() {
+		stop = strconv.FormatInt(toMillis(to), 10)
+	}
+
+	messages, err := r.client.XRange(context.Background(), r.keys.audit(), start, stop).Result()
+
+	if err != nil {
+		return nil, NewError(err, "Could not read audit stream")
+	}
+
+	entries := make([]store.AuditEntry, 0, len(messages))
+	for _, message := range messages {
+		entryState, _ := message.Values["state"].(string)
+		raw, _ := message.Values["entry"].(string)
+
+		if state != "" && !strings.EqualFold(state, entryState) {
+			continue
+		}
+
+		var entry store.AuditEntry
+
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return nil, NewError(err, "Audit entry is not valid json")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
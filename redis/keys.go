@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion is the layout of the keys this version of the bot reads and writes.
+// Version 0 are the unprefixed keys of the first release, migrate moves them to the current layout.
+const SchemaVersion = 1
+
+// keys builds every key the bot uses, namespaced under a prefix so several deployments can share a database.
+// Inline message IDs are scoped to the bot that created them, since no other bot can edit them.
+type keys struct {
+	prefix string
+	bot    string
+}
+
+func (k keys) key(parts ...string) string {
+	return k.prefix + ":" + strings.Join(parts, ":")
+}
+
+func (k keys) schema() string {
+	return k.key("schema")
+}
+
+func (k keys) state(channelId int64, state string) string {
+	return k.key("state", fmt.Sprint(channelId), strings.ToUpper(state))
+}
+
+func (k keys) summary(channelId int64) string {
+	return k.key("summary", fmt.Sprint(channelId))
+}
+
+func (k keys) inline(state string) string {
+	return k.key("inline", k.bot, strings.ToUpper(state))
+}
+
+func (k keys) history() string {
+	return k.key("history")
+}
+
+func (k keys) audit() string {
+	return k.key("audit")
+}
+
+func (k keys) lease(name string) string {
+	return k.key("lease", name)
+}
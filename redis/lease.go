@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// acquireScript takes the lease when nobody holds it and extends it when we already do
+var acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript only deletes the lease when we still hold it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lease is a lock that expires unless its holder keeps acquiring it again before the ttl runs out
+type Lease struct {
+	client *redis.Client
+	key    string
+	holder string
+	ttl    time.Duration
+}
+
+// NewLease creates a lease named name, held by holder once acquired. holder has to be unique per replica.
+func (r *Redis) NewLease(name string, holder string, ttl time.Duration) *Lease {
+	return &Lease{
+		client: r.client,
+		key:    r.keys.lease(name),
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+// Acquire takes or extends the lease, reporting whether we hold it now
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	held, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.holder, l.ttl.Milliseconds()).Int()
+
+	if err != nil {
+		return false, NewError(err, "Could not acquire lease")
+	}
+
+	return held == 1, nil
+}
+
+// Release gives up the lease so a standby can take over right away
+func (l *Lease) Release(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, l.holder).Err(); err != nil && err != redis.Nil {
+		return NewError(err, "Could not release lease")
+	}
+
+	return nil
+}
+
+// TTL is how long the lease lasts without being acquired again
+func (l *Lease) TTL() time.Duration {
+	return l.ttl
+}
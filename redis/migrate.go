@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+)
+
+// Rename is a key moved by a migration
+type Rename struct {
+	From string
+	To   string
+}
+
+// GetSchemaVersion returns the key layout the database is in. Databases from before versioning report 0.
+func (r *Redis) GetSchemaVersion() (int, error) {
+	version, err := r.client.Get(context.Background(), r.keys.schema()).Int()
+
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, NewError(err, "Could not read schema version")
+	}
+
+	return version, nil
+}
+
+// EnsureSchema makes sure the database is in the layout of SchemaVersion.
+// An empty database is stamped with it, anything older has to be migrated first.
+func (r *Redis) EnsureSchema() error {
+	version, err := r.GetSchemaVersion()
+
+	if err != nil {
+		return err
+	}
+
+	if version == SchemaVersion {
+		return nil
+	}
+
+	if version > SchemaVersion {
+		return NewError(fmt.Errorf("database is at schema %d, this bot only knows %d", version, SchemaVersion), "Schema too new")
+	}
+
+	legacy, err := r.legacyRenames()
+
+	if err != nil {
+		return err
+	}
+
+	if len(legacy) > 0 {
+		return NewError(fmt.Errorf("found %d keys of schema %d", len(legacy), version), "Run the migrate command first")
+	}
+
+	return r.setSchemaVersion()
+}
+
+func (r *Redis) setSchemaVersion() error {
+	if err := r.client.Set(context.Background(), r.keys.schema(), SchemaVersion, 0).Err(); err != nil {
+		return NewError(err, "Could not set schema version")
+	}
+
+	return nil
+}
+
+// Migrate moves the keys of older layouts to the current one and stamps the database with SchemaVersion.
+// With dryRun it only reports what it would move. Keys that already exist in the new layout are never overwritten.
+func (r *Redis) Migrate(dryRun bool) ([]Rename, error) {
+	renames, err := r.legacyRenames()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return renames, nil
+	}
+
+	for _, rename := range renames {
+		moved, err := r.client.RenameNX(context.Background(), rename.From, rename.To).Result()
+
+		if err != nil {
+			return nil, NewError(err, "Could not move "+rename.From)
+		}
+
+		if !moved {
+			return nil, NewError(fmt.Errorf("%s already exists", rename.To), "Could not move "+rename.From)
+		}
+
+		r.log.Infof("moved %s to %s", rename.From, rename.To)
+	}
+
+	if err := r.setSchemaVersion(); err != nil {
+		return nil, err
+	}
+
+	return renames, nil
+}
+
+// legacyPatterns match every key of the unversioned layout
+var legacyPatterns = []string{"state-*", "summary-*", "inline-state-*", "history"}
+
+// legacyRenames finds every key of the unversioned layout, and where it goes in the current one
+func (r *Redis) legacyRenames() ([]Rename, error) {
+	renames := make([]Rename, 0)
+
+	for _, pattern := range legacyPatterns {
+		iter := r.client.Scan(context.Background(), 0, pattern, 100).Iterator()
+
+		for iter.Next(context.Background()) {
+			if target, ok := r.keys.legacyTarget(iter.Val()); ok {
+				renames = append(renames, Rename{From: iter.Val(), To: target})
+			}
+		}
+
+		if err := iter.Err(); err != nil {
+			return nil, NewError(err, "Could not scan for "+pattern)
+		}
+	}
+
+	return renames, nil
+}
+
+// legacyTarget is where a key of the unversioned layout goes in the current one.
+// It's false for keys that only look like they're of the old layout, those are left alone.
+func (k keys) legacyTarget(key string) (string, bool) {
+	switch {
+	case strings.HasPrefix(key, "state-"):
+		// state-<channel>-<state>, where the channel ID itself is usually negative
+		rest := strings.TrimPrefix(key, "state-")
+		i := strings.LastIndex(rest, "-")
+
+		if i <= 0 || !isStateCode(rest[i+1:]) {
+			return "", false
+		}
+
+		channelId, err := strconv.ParseInt(rest[:i], 10, 64)
+
+		if err != nil {
+			return "", false
+		}
+
+		return k.state(channelId, rest[i+1:]), true
+	case strings.HasPrefix(key, "summary-"):
+		channelId, err := strconv.ParseInt(strings.TrimPrefix(key, "summary-"), 10, 64)
+
+		if err != nil {
+			return "", false
+		}
+
+		return k.summary(channelId), true
+	case strings.HasPrefix(key, "inline-state-"):
+		// inline IDs weren't scoped per bot, they're assumed to belong to the bot running the migration
+		state := strings.TrimPrefix(key, "inline-state-")
+
+		if !isStateCode(state) {
+			return "", false
+		}
+
+		return k.inline(state), true
+	case key == "history":
+		return k.history(), true
+	default:
+		return "", false
+	}
+}
+
+// isStateCode reports whether s looks like a state abbreviation
+func isStateCode(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,45 @@
+package redis
+
+import "testing"
+
+func TestLegacyTarget(t *testing.T) {
+	k := keys{prefix: "uselections:v1", bot: "123456"}
+
+	tests := []struct {
+		key    string
+		target string
+		ok     bool
+	}{
+		{key: "state--1001234567890-PA", target: "uselections:v1:state:-1001234567890:PA", ok: true},
+		{key: "state-42-ga", target: "uselections:v1:state:42:GA", ok: true},
+		{key: "summary--1001234567890", target: "uselections:v1:summary:-1001234567890", ok: true},
+		{key: "inline-state-WI", target: "uselections:v1:inline:123456:WI", ok: true},
+		{key: "inline-state-az", target: "uselections:v1:inline:123456:AZ", ok: true},
+		{key: "history", target: "uselections:v1:history", ok: true},
+
+		// look like the old layout, but aren't
+		{key: "state-PA"},
+		{key: "state--PA"},
+		{key: "state-abc-PA"},
+		{key: "state--100123-"},
+		{key: "state--100123-P1"},
+		{key: "summary-"},
+		{key: "summary-main"},
+		{key: "inline-state-"},
+		{key: "inline-state-PA:1"},
+
+		// other keys sharing the database
+		{key: "history-backup"},
+		{key: "uselections:v1:state:-1001234567890:PA"},
+		{key: "uselections:v1:history"},
+		{key: "sessions:42"},
+	}
+
+	for _, test := range tests {
+		target, ok := k.legacyTarget(test.key)
+
+		if ok != test.ok || target != test.target {
+			t.Errorf("legacyTarget(%q) = %q, %v, expected %q, %v", test.key, target, ok, test.target, test.ok)
+		}
+	}
+}
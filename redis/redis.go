@@ -2,19 +2,23 @@ package redis
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"github.com/aaomidi/uselections-2020/election"
 	"github.com/go-redis/redis/v8"
 	log "github.com/sirupsen/logrus"
-	"strings"
+	"strconv"
+	"time"
 )
 
 type Redis struct {
 	options *redis.Options
 	client  *redis.Client
+	keys    keys
 	log     *log.Entry
 }
 
-func New(url string) (*Redis, error) {
+// New connects to redis. Every key is put under prefix, and inline message IDs are scoped to botID.
+func New(url string, prefix string, botID string) (*Redis, error) {
 	options, err := redis.ParseURL(url)
 	if err != nil {
 		return nil, NewError(err, "unable to parse redis url")
@@ -25,6 +29,7 @@ func New(url string) (*Redis, error) {
 	self := &Redis{
 		options: options,
 		client:  r,
+		keys:    keys{prefix: prefix, bot: botID},
 		log:     log.WithField("source", "redis"),
 	}
 
@@ -33,9 +38,18 @@ func New(url string) (*Redis, error) {
 	return self, nil
 }
 
+// Ping checks that the redis server is reachable
+func (r *Redis) Ping() error {
+	if err := r.client.Ping(context.Background()).Err(); err != nil {
+		return NewError(err, "redis did not answer ping")
+	}
+
+	return nil
+}
+
 func (r *Redis) GetMessageIdForState(channelId int64, state string) (int, error) {
 	val := r.client.Get(context.Background(),
-		fmt.Sprintf("state-%d-%s", channelId, strings.ToUpper(state)),
+		r.keys.state(channelId, state),
 	)
 
 	messageId, err := val.Int()
@@ -49,7 +63,7 @@ func (r *Redis) GetMessageIdForState(channelId int64, state string) (int, error)
 
 func (r *Redis) SaveMessageIdForState(channelId int64, state string, messageId int) error {
 	err := r.client.Set(context.Background(),
-		fmt.Sprintf("state-%d-%s", channelId, strings.ToUpper(state)),
+		r.keys.state(channelId, state),
 		messageId,
 		0,
 	).Err()
@@ -61,9 +75,37 @@ func (r *Redis) SaveMessageIdForState(channelId int64, state string, messageId i
 	return nil
 }
 
+func (r *Redis) GetSummaryMessageId(channelId int64) (int, error) {
+	val := r.client.Get(context.Background(),
+		r.keys.summary(channelId),
+	)
+
+	messageId, err := val.Int()
+
+	if err != nil {
+		return 0, NewError(err, "Summary message ID did not convert to int")
+	}
+
+	return messageId, nil
+}
+
+func (r *Redis) SaveSummaryMessageId(channelId int64, messageId int) error {
+	err := r.client.Set(context.Background(),
+		r.keys.summary(channelId),
+		messageId,
+		0,
+	).Err()
+
+	if err != nil {
+		return NewError(err, "Could not set summary message id in redis")
+	}
+
+	return nil
+}
+
 func (r *Redis) SaveInlineMessageId(state string, inlineMessageId string) error {
 	err := r.client.RPush(context.Background(),
-		fmt.Sprintf("inline-state-%s", strings.ToUpper(state)),
+		r.keys.inline(state),
 		inlineMessageId,
 	).Err()
 
@@ -76,7 +118,7 @@ func (r *Redis) SaveInlineMessageId(state string, inlineMessageId string) error
 
 func (r *Redis) GetInlineMessageId(state string) ([]string, error) {
 	result := r.client.LRange(context.Background(),
-		fmt.Sprintf("inline-state-%s", strings.ToUpper(state)),
+		r.keys.inline(state),
 		0, -1,
 	)
 
@@ -89,7 +131,7 @@ func (r *Redis) GetInlineMessageId(state string) ([]string, error) {
 
 func (r *Redis) RemoveInlineMessageId(state string, msgId string) error {
 	err := r.client.LRem(context.Background(),
-		fmt.Sprintf("inline-state-%s", strings.ToUpper(state)),
+		r.keys.inline(state),
 		0,
 		msgId,
 	).Err()
@@ -100,3 +142,86 @@ func (r *Redis) RemoveInlineMessageId(state string, msgId string) error {
 
 	return nil
 }
+
+// SaveSnapshot adds a snapshot to the history, a sorted set scored by the snapshot's time in milliseconds
+func (r *Redis) SaveSnapshot(snapshot election.Snapshot) error {
+	raw, err := json.Marshal(snapshot)
+
+	if err != nil {
+		return NewError(err, "Could not encode snapshot")
+	}
+
+	err = r.client.ZAdd(context.Background(), r.keys.history(), &redis.Z{
+		Score:  float64(toMillis(snapshot.Time)),
+		Member: raw,
+	}).Err()
+
+	if err != nil {
+		return NewError(err, "Could not save snapshot")
+	}
+
+	return nil
+}
+
+func (r *Redis) GetSnapshots(from time.Time, to time.Time) ([]election.Snapshot, error) {
+	min, max := "-inf", "+inf"
+
+	if !from.IsZero() {
+		min = strconv.FormatInt(toMillis(from), 10)
+	}
+
+	if !to.IsZero() {
+		max = strconv.FormatInt(toMillis(to), 10)
+	}
+
+	result := r.client.ZRangeByScore(context.Background(), r.keys.history(), &redis.ZRangeBy{
+		Min: min,
+		Max: max,
+	})
+
+	if result.Err() != nil {
+		return nil, NewError(result.Err(), "Could not read history")
+	}
+
+	return decodeSnapshots(result.Val())
+}
+
+func (r *Redis) GetLatestSnapshot() (election.Snapshot, error) {
+	result := r.client.ZRevRange(context.Background(), r.keys.history(), 0, 0)
+
+	if result.Err() != nil {
+		return election.Snapshot{}, NewError(result.Err(), "Could not read history")
+	}
+
+	snapshots, err := decodeSnapshots(result.Val())
+
+	if err != nil {
+		return election.Snapshot{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return election.Snapshot{}, NewError(redis.Nil, "No snapshots recorded")
+	}
+
+	return snapshots[0], nil
+}
+
+func decodeSnapshots(members []string) ([]election.Snapshot, error) {
+	snapshots := make([]election.Snapshot, 0, len(members))
+
+	for _, member := range members {
+		var snapshot election.Snapshot
+
+		if err := json.Unmarshal([]byte(member), &snapshot); err != nil {
+			return nil, NewError(err, "Snapshot is not valid json")
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	log "github.com/sirupsen/logrus"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CountyURLPattern is the per state county file, formatted with the state abbreviation
+	CountyURLPattern = BaseURL + "/counties/%s.json"
+)
+
+// CountyURLPatternAt is CountyURLPattern on another server, base replacing BaseURL
+func CountyURLPatternAt(base string) string {
+	return strings.TrimRight(base, "/") + "/counties/%s.json"
+}
+
+// NPRCountyScraper is an implementation of the Scraper interface
+// using NPR's per state county files. Every vote it sends has County set.
+type NPRCountyScraper struct {
+	fetcher    *fetcher
+	urlPattern string
+	log        *log.Entry
+
+	// mu guards updated, the Updated timestamps of the last scrape of every state
+	mu      sync.Mutex
+	updated map[string]map[string]int64
+
+	// latest are the last county votes of every state, so unchanged states are still part of a scrape
+	latest map[string][]election.Vote
+}
+
+// NewNPRCountyScraper creates a county scraper whose requests give up after timeout.
+// urlPattern is formatted with the state abbreviation, CountyURLPattern is used when it's empty.
+func NewNPRCountyScraper(timeout time.Duration, urlPattern string) *NPRCountyScraper {
+	if urlPattern == "" {
+		urlPattern = CountyURLPattern
+	}
+
+	return &NPRCountyScraper{
+		fetcher:    newFetcher(timeout),
+		urlPattern: urlPattern,
+		log:        log.WithField("source", "counties"),
+		updated:    make(map[string]map[string]int64),
+		latest:     make(map[string][]election.Vote),
+	}
+}
+
+// Scrape fetches the county file of every watched state. A state that fails keeps its last counties,
+// the scrape only fails when every state did.
+func (npr *NPRCountyScraper) Scrape(ctx context.Context) (<-chan election.Vote, error) {
+	results := make([]election.Vote, 0, 1024)
+	changed := false
+
+	states := election.GetStates()
+	failed := 0
+	var lastErr error
+
+	for _, state := range states {
+		votes, err := npr.Fetch(ctx, state.Abbreviation)
+
+		switch {
+		case err == ErrNotModified:
+			votes = npr.latest[state.Abbreviation]
+		case err != nil:
+			npr.log.WithError(err).Warnf("skipping the counties of %s", state.Abbreviation)
+			failed++
+			lastErr = err
+			votes = npr.latest[state.Abbreviation]
+		default:
+			changed = true
+			npr.latest[state.Abbreviation] = votes
+		}
+
+		results = append(results, votes...)
+	}
+
+	if failed > 0 && failed == len(states) {
+		return nil, lastErr
+	}
+
+	if !changed {
+		return nil, ErrNotModified
+	}
+
+	channel := make(chan election.Vote)
+
+	go func() {
+		for _, vote := range results {
+			channel <- vote
+		}
+
+		close(channel)
+	}()
+
+	return channel, nil
+}
+
+// Fetch downloads the county results of a single state. It returns ErrNotModified when nothing in the state changed.
+func (npr *NPRCountyScraper) Fetch(ctx context.Context, state string) ([]election.Vote, error) {
+	var nprData = &NPRStateData{}
+
+	if err := npr.fetcher.fetch(ctx, fmt.Sprintf(npr.urlPattern, state), nprData); err != nil {
+		return nil, err
+	}
+
+	updated := nprData.updatedIndex()
+
+	npr.mu.Lock()
+	defer npr.mu.Unlock()
+
+	if previous, ok := npr.updated[state]; ok && reflect.DeepEqual(updated, previous) {
+		return nil, ErrNotModified
+	}
+
+	npr.updated[state] = updated
+
+	return nprData.TransformCounties(), nil
+}
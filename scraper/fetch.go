@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// fetcher downloads JSON documents with conditional requests, remembering the validators of every URL
+type fetcher struct {
+	client *http.Client
+
+	mu         sync.Mutex
+	validators map[string]validators
+}
+
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+func newFetcher(timeout time.Duration) *fetcher {
+	return &fetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				TLSHandshakeTimeout:   timeout,
+				ResponseHeaderTimeout: timeout,
+				IdleConnTimeout:       90 * time.Second,
+				MaxIdleConnsPerHost:   2,
+			},
+		},
+		validators: make(map[string]validators),
+	}
+}
+
+// fetch decodes the document at url into v. It returns ErrNotModified without touching v
+// when the server says the document didn't change since the last fetch.
+func (f *fetcher) fetch(ctx context.Context, url string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	// Setting this ourselves turns off the transport's transparent decompression, so we handle it below
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	f.mu.Lock()
+	previous := f.validators[url]
+	f.mu.Unlock()
+
+	if previous.etag != "" {
+		request.Header.Set("If-None-Match", previous.etag)
+	}
+
+	if previous.lastModified != "" {
+		request.Header.Set("If-Modified-Since", previous.lastModified)
+	}
+
+	response, err := f.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// drain whatever the decoder didn't read so the connection can be reused
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode == http.StatusNotModified {
+		return ErrNotModified
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %s: %s", url, response.Status)
+	}
+
+	var body io.Reader = response.Body
+
+	if response.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(response.Body)
+
+		if err != nil {
+			return err
+		}
+
+		defer gz.Close()
+
+		body = gz
+	}
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.validators[url] = validators{
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}
+	f.mu.Unlock()
+
+	return nil
+}
@@ -2,17 +2,27 @@ package scraper
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/aaomidi/uselections-2020/election"
-	"io/ioutil"
-	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
+	// BaseURL is where NPR publishes every data file
+	BaseURL = "https://apps.npr.org/elections20-interactive/data"
+
 	// AllStatesURL shows every state
-	AllStatesURL = "https://apps.npr.org/elections20-interactive/data/president.json"
+	AllStatesURL = BaseURL + "/president.json"
 )
 
+// AllStatesURLAt is AllStatesURL on another server, base replacing BaseURL
+func AllStatesURLAt(base string) string {
+	return strings.TrimRight(base, "/") + "/president.json"
+}
+
 type NPRStateData struct {
 	Results []NPRElectionData
 }
@@ -23,12 +33,10 @@ func (data *NPRStateData) Transform() []election.Vote {
 	stateNormalized := make(map[StateCandidate]election.Vote)
 
 	for _, result := range data.Results {
-		// currently we only care about president. NPR offers senate. If we have time (which we likely won't)
-		// We can add senate and house since the data is easily accessible.
-		if !result.Test && result.Office == "P" {
+		// the election definition says which of the races NPR offers we follow
+		if !result.Test && election.FollowsOffice(result.Office) {
 			for _, vote := range result.Transform() {
-				deduplicateVote(stateNormalized, vote)
-
+				deduplicateVote(stateNormalized, vote, result.Level == "state")
 			}
 		}
 	}
@@ -38,18 +46,69 @@ func (data *NPRStateData) Transform() []election.Vote {
 		votes = append(votes, val)
 	}
 
+	// the map hands them out in random order, the same results have to come out the same for change detection
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].State.Abbreviation != votes[j].State.Abbreviation {
+			return votes[i].State.Abbreviation < votes[j].State.Abbreviation
+		}
+
+		return votes[i].Candidate.Key() < votes[j].Candidate.Key()
+	})
+
 	return votes
 }
 
+// TransformCounties transforms the county level results of a county file to our data format
+func (data *NPRStateData) TransformCounties() []election.Vote {
+	var votes []election.Vote
+
+	for _, result := range data.Results {
+		if result.Test || !election.FollowsOffice(result.Office) || result.FIPS == "" {
+			continue
+		}
+
+		county := &election.County{
+			FIPS: result.FIPS,
+			Name: result.County,
+			State: election.State{
+				Name:         result.StateName,
+				Abbreviation: result.State,
+			},
+		}
+
+		for _, vote := range result.Transform() {
+			vote.County = county
+			// the population is statewide, a county's share of it isn't a turnout
+			vote.StateVote.TurnoutPercentage = 0
+			votes = append(votes, vote)
+		}
+	}
+
+	return votes
+}
+
+// updatedIndex maps every race to the time NPR last updated it
+func (data *NPRStateData) updatedIndex() map[string]int64 {
+	index := make(map[string]int64, len(data.Results))
+
+	for _, result := range data.Results {
+		index[result.Office+"-"+result.State+"-"+result.District+"-"+result.FIPS+"-"+result.Level] = result.Updated
+	}
+
+	return index
+}
+
 type StateCandidate struct {
 	state     string
 	candidate string
 }
 
-func deduplicateVote(m map[StateCandidate]election.Vote, vote election.Vote) {
+// deduplicateVote merges the district results of Maine and Nebraska into the statewide one.
+// The electoral votes add up, the counts are the statewide ones since the districts are part of them.
+func deduplicateVote(m map[StateCandidate]election.Vote, vote election.Vote, statewide bool) {
 	c := StateCandidate{
 		state:     vote.State.Abbreviation,
-		candidate: vote.Candidate.LastName,
+		candidate: vote.Candidate.Key(),
 	}
 
 	existing, ok := m[c]
@@ -59,12 +118,20 @@ func deduplicateVote(m map[StateCandidate]election.Vote, vote election.Vote) {
 		return
 	}
 
-	existing.ElectoralVotes = existing.ElectoralVotes + vote.ElectoralVotes
-	existing.Count = existing.Count + vote.Count
-	existing.Percentage = existing.Percentage + vote.Percentage
+	if statewide {
+		vote.ElectoralVotes += existing.ElectoralVotes
+		m[c] = vote
+		return
+	}
+
+	existing.ElectoralVotes += vote.ElectoralVotes
+	m[c] = existing
 }
 
 type NPRElectionData struct {
+	// The stable ID of the race
+	ID string
+
 	// If this is test data - hopefully we won't come across this in production. that'd be embarrassing
 	Test bool
 
@@ -94,10 +161,14 @@ type NPRElectionData struct {
 	// The district - only used in 2 states
 	District string
 
+	// The county FIPS code and name - only set in the per state county files
+	FIPS   string
+	County string
+
 	// The total number of precincts
 	Precincts int
 
-	// The timestamp of when the information was last updated
+	// The timestamp of when the information was last updated, in milliseconds since the epoch
 	Updated int64
 
 	// The total number of precincts reporting
@@ -115,6 +186,7 @@ type NPRElectionData struct {
 
 func (data *NPRElectionData) Transform() []election.Vote {
 	stateResults := election.StateResults{
+		RaceID: data.ID,
 		State: election.State{
 			Name:         data.StateName,
 			Abbreviation: data.State,
@@ -122,25 +194,34 @@ func (data *NPRElectionData) Transform() []election.Vote {
 		ReportingCount:      data.Reporting,
 		ReportingPercentage: data.ReportingPercent,
 		TotalPrecincts:      data.Precincts,
+		Updated:             time.Unix(0, data.Updated*int64(time.Millisecond)),
 	}
 
-	var votes []election.Vote
-
 	for _, candidate := range data.Candidates {
 		stateResults.TotalVotes += candidate.Votes
+		stateResults.AbsenteeVotes += candidate.AVotes
+	}
+
+	stateResults.TurnoutPercentage = election.GetTurnout(data.State, stateResults.TotalVotes)
+
+	var votes []election.Vote
 
+	for _, candidate := range data.Candidates {
 		vote := election.Vote{
-			Candidate: election.Candidate{
+			Candidate: election.CompleteCandidate(election.Candidate{
+				ID:        candidate.ID,
 				FirstName: candidate.First,
 				LastName:  candidate.Last,
 				Party:     election.GetParty(candidate.Party),
-			},
+				Incumbent: candidate.Incumbent,
+			}),
 			State: election.State{
 				Name:         data.StateName,
 				Abbreviation: data.State,
 			},
 			Percentage:     candidate.Percent,
 			Count:          candidate.Votes,
+			AbsenteeCount:  candidate.AVotes,
 			ElectoralVotes: candidate.Electoral,
 			StateVote:      stateResults,
 		}
@@ -152,6 +233,9 @@ func (data *NPRElectionData) Transform() []election.Vote {
 }
 
 type NPRCandidateData struct {
+	// The stable ID of the candidate, the same in every state
+	ID string
+
 	// The first and last names of the candidates on the ballot
 	// NPR doesn't list the third party candidates from what it seems so it's listed as "other"
 	First, Last string
@@ -162,7 +246,7 @@ type NPRCandidateData struct {
 	// The total number of votes a candidate has
 	Votes int64
 
-	// I have no idea what this field is for. I assume absentee ballots?
+	// The part of Votes that was cast absentee or by mail
 	AVotes int64
 
 	// Is the candidate incumbent?
@@ -180,29 +264,48 @@ type NPRCandidateData struct {
 // NPRScraper is an implementation of the Scraper interface
 // using the NPR interactive election data
 // URL: https://apps.npr.org/elections20-interactive/data/president.json
-type NPRScraper struct{}
+type NPRScraper struct {
+	fetcher *fetcher
+	url     string
 
-func (npr *NPRScraper) Scrape(ctx context.Context) <-chan election.Vote {
-	channel := make(chan election.Vote)
+	// mu guards updated, the Updated timestamps of the last scrape
+	mu      sync.Mutex
+	updated map[string]int64
+}
+
+// NewNPRScraper creates a scraper whose requests give up after timeout.
+// url is the file with every state, AllStatesURL is used when it's empty.
+func NewNPRScraper(timeout time.Duration, url string) *NPRScraper {
+	if url == "" {
+		url = AllStatesURL
+	}
+
+	return &NPRScraper{
+		fetcher: newFetcher(timeout),
+		url:     url,
+	}
+}
 
-	go func(ctx context.Context) {
-		state := npr.getStateFromContext(ctx)
+func (npr *NPRScraper) Scrape(ctx context.Context) (<-chan election.Vote, error) {
+	state := npr.getStateFromContext(ctx)
 
-		results, err := npr.Fetch(state)
+	results, err := npr.Fetch(ctx, state)
 
-		if err != nil {
-			// not sure what to do here ngl. it's 3am and i'm too tired. do we cancel the context?
-			panic(err)
-		}
+	if err != nil {
+		return nil, err
+	}
 
+	channel := make(chan election.Vote)
+
+	go func() {
 		for _, vote := range results {
 			channel <- vote
 		}
 
 		close(channel) // close the channel when we're done sending the items
-	}(ctx)
+	}()
 
-	return channel
+	return channel, nil
 }
 
 func (npr *NPRScraper) getStateFromContext(ctx context.Context) string {
@@ -215,32 +318,26 @@ func (npr *NPRScraper) getStateFromContext(ctx context.Context) string {
 	return state.(string)
 }
 
-func (npr *NPRScraper) Fetch(state string) ([]election.Vote, error) {
-	response, err := http.Get(AllStatesURL)
+// Fetch downloads and transforms the results. It sends a conditional request using the validators
+// of the last response, and returns ErrNotModified when NPR answers with a 304 or when none of the
+// races' Updated timestamps moved.
+func (npr *NPRScraper) Fetch(ctx context.Context, state string) ([]election.Vote, error) {
+	var nprData = &NPRStateData{}
 
-	if err != nil {
+	if err := npr.fetcher.fetch(ctx, npr.url, nprData); err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			panic(err) // i feel like panicking isn't great
-		}
-	}()
+	updated := nprData.updatedIndex()
 
-	data, err := ioutil.ReadAll(response.Body)
+	npr.mu.Lock()
+	defer npr.mu.Unlock()
 
-	if err != nil {
-		return nil, err
+	if npr.updated != nil && reflect.DeepEqual(updated, npr.updated) {
+		return nil, ErrNotModified
 	}
 
-	var nprData = &NPRStateData{}
-
-	err = json.Unmarshal(data, nprData)
-
-	if err != nil {
-		return nil, err
-	}
+	npr.updated = updated
 
 	transformed := nprData.Transform()
 
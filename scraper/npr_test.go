@@ -0,0 +1,37 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdenticalResultsTransformTheSame(t *testing.T) {
+	data := &NPRStateData{}
+
+	for _, state := range []string{"AZ", "GA", "ME", "MI", "NC", "NV", "PA", "WI"} {
+		data.Results = append(data.Results, NPRElectionData{
+			Office:    "P",
+			Level:     "state",
+			State:     state,
+			StateName: state,
+			Candidates: []NPRCandidateData{
+				{First: "Joe", Last: "Biden", Party: "Dem", Votes: 1000},
+				{First: "Donald", Last: "Trump", Party: "GOP", Votes: 900},
+				{First: "Jo", Last: "Jorgensen", Party: "Lib", Votes: 30},
+			},
+		})
+	}
+
+	// the data pipeline compares two scrapes with reflect.DeepEqual, the same numbers mustn't look like a change
+	first := data.Transform()
+
+	if len(first) != 24 {
+		t.Fatalf("expected 24 votes, got %d", len(first))
+	}
+
+	for i := 0; i < 20; i++ {
+		if again := data.Transform(); !reflect.DeepEqual(first, again) {
+			t.Fatalf("transforming the same results twice gave a different order on try %d", i)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package nprtest
+
+import (
+	"github.com/aaomidi/uselections-2020/scraper"
+	"sort"
+	"time"
+)
+
+// Start is the Updated timestamp of the first step, every step is stamped with Start plus its At
+var Start = time.Date(2020, time.November, 4, 0, 0, 0, 0, time.UTC)
+
+// Race is a state of the scripted results
+type Race struct {
+	State     string
+	Name      string
+	Electoral int
+	Precincts int
+
+	// Expected is the total the state ends up at, the reporting percentage is worked out from it
+	Expected int64
+
+	// Counties stand in for the whole state in its county file, splitting its votes between them
+	Counties []County
+}
+
+// County is a county of a race and the share of the state's votes it gets
+type County struct {
+	FIPS  string
+	Name  string
+	Share float64
+}
+
+// Races are the states every built in scenario reports
+var Races = []Race{
+	{State: "PA", Name: "Pennsylvania", Electoral: 20, Precincts: 9150, Expected: 6900000, Counties: []County{
+		{FIPS: "42101", Name: "Philadelphia", Share: 0.4},
+		{FIPS: "42003", Name: "Allegheny", Share: 0.6},
+	}},
+	{State: "GA", Name: "Georgia", Electoral: 16, Precincts: 2655, Expected: 5000000, Counties: []County{
+		{FIPS: "13121", Name: "Fulton", Share: 0.45},
+		{FIPS: "13135", Name: "Gwinnett", Share: 0.55},
+	}},
+	{State: "AZ", Name: "Arizona", Electoral: 11, Precincts: 1489, Expected: 3400000, Counties: []County{
+		{FIPS: "04013", Name: "Maricopa", Share: 0.6},
+		{FIPS: "04019", Name: "Pima", Share: 0.4},
+	}},
+	{State: "WI", Name: "Wisconsin", Electoral: 10, Precincts: 3620, Expected: 3300000, Counties: []County{
+		{FIPS: "55079", Name: "Milwaukee", Share: 0.45},
+		{FIPS: "55025", Name: "Dane", Share: 0.55},
+	}},
+}
+
+// Candidate is a candidate of the scripted results
+type Candidate struct {
+	ID          string
+	First, Last string
+	Party       string
+	Incumbent   bool
+}
+
+var (
+	Biden     = Candidate{ID: "1036", First: "Joe", Last: "Biden", Party: "Dem"}
+	Trump     = Candidate{ID: "8639", First: "Donald", Last: "Trump", Party: "GOP", Incumbent: true}
+	Jorgensen = Candidate{ID: "64984", First: "Jo", Last: "Jorgensen", Party: "Lib"}
+)
+
+// Count is the votes of a candidate in a state
+type Count struct {
+	Candidate Candidate
+	Votes     int64
+}
+
+// Results builds the data file of a step at the given time, counts keyed by state abbreviation.
+// States missing from counts aren't in the file.
+func Results(at time.Duration, counts map[string][]Count) *scraper.NPRStateData {
+	data := &scraper.NPRStateData{}
+
+	for _, race := range Races {
+		if stateCounts, ok := counts[race.State]; ok {
+			data.Results = append(data.Results, race.result(at, stateCounts, 1, race.Expected))
+		}
+	}
+
+	return data
+}
+
+// CountyResults builds the county files of a step at the given time, keyed by state abbreviation like counts.
+// Every count is split between the counties of its race by their Share.
+func CountyResults(at time.Duration, counts map[string][]Count) map[string]*scraper.NPRStateData {
+	files := make(map[string]*scraper.NPRStateData, len(counts))
+
+	for _, race := range Races {
+		stateCounts, ok := counts[race.State]
+
+		if !ok {
+			continue
+		}
+
+		data := &scraper.NPRStateData{}
+
+		for _, county := range race.Counties {
+			result := race.result(at, stateCounts, county.Share, int64(float64(race.Expected)*county.Share))
+			result.ID += "-" + county.FIPS
+			result.Level = "county"
+			result.FIPS = county.FIPS
+			result.County = county.Name
+
+			data.Results = append(data.Results, result)
+		}
+
+		files[race.State] = data
+	}
+
+	return files
+}
+
+// result is the race with share of every count, out of expected votes
+func (race Race) result(at time.Duration, counts []Count, share float64, expected int64) scraper.NPRElectionData {
+	var total int64
+	votes := make([]int64, len(counts))
+
+	for i, count := range counts {
+		votes[i] = int64(float64(count.Votes) * share)
+		total += votes[i]
+	}
+
+	reporting := float64(total) / float64(expected)
+	if reporting > 1 {
+		reporting = 1
+	}
+
+	precincts := int(float64(race.Precincts) * share)
+
+	result := scraper.NPRElectionData{
+		ID:               race.State + "-P",
+		Office:           "P",
+		Type:             "general",
+		Level:            "state",
+		State:            race.State,
+		StateName:        race.Name,
+		StateAP:          race.State,
+		Precincts:        precincts,
+		Reporting:        int(reporting * float64(precincts)),
+		ReportingPercent: reporting,
+		Updated:          Start.Add(at).UnixNano() / int64(time.Millisecond),
+		Electoral:        race.Electoral,
+	}
+
+	for i, count := range counts {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(votes[i]) / float64(total)
+		}
+
+		result.Candidates = append(result.Candidates, scraper.NPRCandidateData{
+			ID:        count.Candidate.ID,
+			First:     count.Candidate.First,
+			Last:      count.Candidate.Last,
+			Party:     count.Candidate.Party,
+			Votes:     votes[i],
+			Incumbent: count.Candidate.Incumbent,
+			Percent:   percent,
+		})
+	}
+
+	return result
+}
+
+// step serves the statewide results and the county files of counts from at on
+func step(at time.Duration, counts map[string][]Count) Step {
+	return Step{
+		At:       at,
+		Data:     Results(at, counts),
+		Counties: CountyResults(at, counts),
+	}
+}
+
+// twoWay is the counts of every race with Biden at dem and Trump at rep thousand votes,
+// overridden where a state is given in overrides
+func twoWay(dem int64, rep int64, overrides map[string][]Count) map[string][]Count {
+	counts := make(map[string][]Count, len(Races))
+
+	for _, race := range Races {
+		counts[race.State] = []Count{
+			{Candidate: Biden, Votes: dem * 1000},
+			{Candidate: Trump, Votes: rep * 1000},
+		}
+	}
+
+	for state, count := range overrides {
+		counts[state] = count
+	}
+
+	return counts
+}
+
+// LeadFlip has Trump lead Pennsylvania until t=30s, when the mail-in ballots put Biden ahead
+func LeadFlip() Scenario {
+	return Scenario{
+		Name: "lead-flip",
+		Steps: []Step{
+			step(0, twoWay(1000, 1050, pennsylvania(2400, 2900))),
+			step(15*time.Second, twoWay(1100, 1120, pennsylvania(2900, 3150))),
+			step(30*time.Second, twoWay(1200, 1190, pennsylvania(3350, 3300))),
+		},
+	}
+}
+
+// BadGateway answers with 502s from t=10s to t=20s, and with newer results after
+func BadGateway() Scenario {
+	return Scenario{
+		Name: "bad-gateway",
+		Steps: []Step{
+			step(0, twoWay(1000, 1000, nil)),
+			{At: 10 * time.Second, Status: 502},
+			step(20*time.Second, twoWay(1100, 1080, nil)),
+		},
+	}
+}
+
+// Truncated cuts the body off halfway from t=10s to t=15s, and serves newer results after
+func Truncated() Scenario {
+	truncated := step(10*time.Second, twoWay(1050, 1040, nil))
+	truncated.Truncate = true
+
+	return Scenario{
+		Name: "truncated",
+		Steps: []Step{
+			step(0, twoWay(1000, 1000, nil)),
+			truncated,
+			step(15*time.Second, twoWay(1100, 1080, nil)),
+		},
+	}
+}
+
+// NewCandidate adds Jorgensen to the ballot of every state at t=30s
+func NewCandidate() Scenario {
+	withJorgensen := make(map[string][]Count, len(Races))
+	for _, race := range Races {
+		withJorgensen[race.State] = []Count{
+			{Candidate: Biden, Votes: 1100000},
+			{Candidate: Trump, Votes: 1080000},
+			{Candidate: Jorgensen, Votes: 30000},
+		}
+	}
+
+	return Scenario{
+		Name: "new-candidate",
+		Steps: []Step{
+			step(0, twoWay(1000, 1000, nil)),
+			step(30*time.Second, withJorgensen),
+		},
+	}
+}
+
+// Jump has Pennsylvania's total grow eightfold at t=10s, more than any real batch would, so validation holds it back
+func Jump() Scenario {
+	return Scenario{
+		Name: "jump",
+		Steps: []Step{
+			step(0, twoWay(1000, 1000, pennsylvania(400, 450))),
+			step(10*time.Second, twoWay(1000, 1000, pennsylvania(3350, 3300))),
+		},
+	}
+}
+
+// pennsylvania is the overrides for twoWay with Biden at dem and Trump at rep thousand votes in Pennsylvania
+func pennsylvania(dem int64, rep int64) map[string][]Count {
+	return map[string][]Count{
+		"PA": {{Candidate: Biden, Votes: dem * 1000}, {Candidate: Trump, Votes: rep * 1000}},
+	}
+}
+
+var scenarios = map[string]func() Scenario{
+	"lead-flip":     LeadFlip,
+	"bad-gateway":   BadGateway,
+	"truncated":     Truncated,
+	"new-candidate": NewCandidate,
+	"jump":          Jump,
+}
+
+// GetScenario returns a built in scenario by name
+func GetScenario(name string) (Scenario, bool) {
+	scenario, ok := scenarios[name]
+
+	if !ok {
+		return Scenario{}, false
+	}
+
+	return scenario(), true
+}
+
+// ScenarioNames lists the built in scenarios, sorted
+func ScenarioNames() []string {
+	names := make([]string, 0, len(scenarios))
+	for name := range scenarios {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
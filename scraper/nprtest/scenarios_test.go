@@ -0,0 +1,194 @@
+package nprtest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/scraper"
+	"github.com/aaomidi/uselections-2020/scraper/nprtest"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape runs a scraper once and collects what it sent
+func scrape(s scraper.Scraper) ([]election.Vote, error) {
+	results, err := s.Scrape(context.Background())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []election.Vote
+	for vote := range results {
+		votes = append(votes, vote)
+	}
+
+	return votes, nil
+}
+
+// countOf returns the votes of the candidate in the state, summed over counties when there are any
+func countOf(votes []election.Vote, state string, lastName string) int64 {
+	var count int64
+
+	for _, vote := range votes {
+		if vote.State.Abbreviation == state && vote.Candidate.LastName == lastName {
+			count += vote.Count
+		}
+	}
+
+	return count
+}
+
+func TestBadGateway(t *testing.T) {
+	server := nprtest.NewServer(nprtest.BadGateway())
+	defer server.Close()
+	server.Advance(0)
+
+	npr := scraper.NewNPRScraper(time.Second, scraper.AllStatesURLAt(server.URL))
+
+	votes, err := scrape(npr)
+	if err != nil {
+		t.Fatalf("first scrape failed: %v", err)
+	}
+
+	if count := countOf(votes, "PA", "Biden"); count != 1000000 {
+		t.Errorf("Biden has %d votes in PA, expected 1000000", count)
+	}
+
+	server.Advance(10 * time.Second)
+
+	if _, err := scrape(npr); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected a 502 during the outage, got %v", err)
+	}
+
+	server.Advance(10 * time.Second)
+
+	votes, err = scrape(npr)
+	if err != nil {
+		t.Fatalf("scrape after the outage failed: %v", err)
+	}
+
+	if count := countOf(votes, "PA", "Biden"); count != 1100000 {
+		t.Errorf("Biden has %d votes in PA after the outage, expected 1100000", count)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	server := nprtest.NewServer(nprtest.Truncated())
+	defer server.Close()
+	server.Advance(0)
+
+	npr := scraper.NewNPRScraper(time.Second, scraper.AllStatesURLAt(server.URL))
+
+	if _, err := scrape(npr); err != nil {
+		t.Fatalf("first scrape failed: %v", err)
+	}
+
+	server.Advance(10 * time.Second)
+
+	if _, err := scrape(npr); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected the truncated body not to decode, got %v", err)
+	}
+
+	server.Advance(5 * time.Second)
+
+	votes, err := scrape(npr)
+	if err != nil {
+		t.Fatalf("scrape after the truncated body failed: %v", err)
+	}
+
+	if count := countOf(votes, "WI", "Trump"); count != 1080000 {
+		t.Errorf("Trump has %d votes in WI, expected 1080000", count)
+	}
+}
+
+func TestCounties(t *testing.T) {
+	server := nprtest.NewServer(nprtest.LeadFlip())
+	defer server.Close()
+	server.Advance(0)
+
+	counties := scraper.NewNPRCountyScraper(time.Second, scraper.CountyURLPatternAt(server.URL))
+
+	votes, err := scrape(counties)
+	if err != nil {
+		t.Fatalf("county scrape failed: %v", err)
+	}
+
+	for _, vote := range votes {
+		if vote.County == nil {
+			t.Fatalf("county scrape sent a statewide vote for %s", vote.State.Abbreviation)
+		}
+	}
+
+	if count := countOf(votes, "PA", "Trump"); count != 2900000 {
+		t.Errorf("Trump has %d votes over the PA counties, expected 2900000", count)
+	}
+
+	server.Advance(30 * time.Second)
+
+	votes, err = scrape(counties)
+	if err != nil {
+		t.Fatalf("county scrape after the flip failed: %v", err)
+	}
+
+	if count := countOf(votes, "PA", "Biden"); count != 3350000 {
+		t.Errorf("Biden has %d votes over the PA counties after the flip, expected 3350000", count)
+	}
+}
+
+func TestJumpIsQuarantined(t *testing.T) {
+	server := nprtest.NewServer(nprtest.Jump())
+	defer server.Close()
+	server.Advance(0)
+
+	d := data.New(data.Settings{
+		CloseMargin:      0.005,
+		PercentTolerance: 0.02,
+		MaxJump:          0.5,
+		MinJumpVotes:     50000,
+	})
+	d.Start(data.Source{
+		Name:     "npr",
+		Scraper:  scraper.NewNPRScraper(time.Second, scraper.AllStatesURLAt(server.URL)),
+		Schedule: &data.Schedule{Interval: 10 * time.Millisecond, Min: 10 * time.Millisecond, Max: 10 * time.Millisecond},
+	})
+	defer d.Stop()
+
+	updates := make(chan data.OutgoingUpdate, 2)
+	d.RegisterDataReceiver(updates)
+
+	first := receive(t, updates)
+	if first.Quarantined {
+		t.Fatalf("the first snapshot was quarantined: %v", first.Anomalies)
+	}
+
+	if count := countOf(first.Votes, "PA", "Trump"); count != 450000 {
+		t.Errorf("Trump has %d votes in PA, expected 450000", count)
+	}
+
+	server.Advance(10 * time.Second)
+
+	jump := receive(t, updates)
+	if !jump.Quarantined {
+		t.Fatalf("the jump was published with %d votes for Biden in PA", countOf(jump.Votes, "PA", "Biden"))
+	}
+
+	if len(jump.Anomalies) != 1 || jump.Anomalies[0].State != "PA" {
+		t.Errorf("expected a single anomaly in PA, got %v", jump.Anomalies)
+	}
+}
+
+func receive(t *testing.T, updates <-chan data.OutgoingUpdate) data.OutgoingUpdate {
+	t.Helper()
+
+	select {
+	case update := <-updates:
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update within 5s")
+		return data.OutgoingUpdate{}
+	}
+}
@@ -0,0 +1,207 @@
+// Package nprtest serves NPR data files from scripted scenarios, so the scraper and the data pipeline
+// can be tested against leads flipping, outages and broken responses without waiting for election night.
+//
+// Give the scrapers AllStatesURLAt(Server.URL) and CountyURLPatternAt(Server.URL), and move the scenario along with Advance, or let it play
+// out in real time.
+package nprtest
+
+import (
+	"encoding/json"
+	"github.com/aaomidi/uselections-2020/scraper"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scenario is a timeline of what the server answers with
+type Scenario struct {
+	Name  string
+	Steps []Step
+}
+
+// Step is what the server answers with from At on, until the next step
+type Step struct {
+	At time.Duration
+
+	// Data is served from this step on. Nil keeps serving the data of the step before.
+	Data *scraper.NPRStateData
+
+	// Counties are the county files served from this step on, keyed by state abbreviation.
+	// A state missing from them keeps serving its file of the step before.
+	Counties map[string]*scraper.NPRStateData
+
+	// Status answers with this status and no data instead, when set
+	Status int
+
+	// Truncate cuts the body off halfway, like a connection dropped mid download
+	Truncate bool
+}
+
+// Server serves president.json and the county files out of a scenario
+type Server struct {
+	// URL is the base URL to give the scraper instead of scraper.BaseURL
+	URL string
+
+	scenario Scenario
+	server   *httptest.Server
+
+	mu       sync.Mutex
+	started  time.Time
+	elapsed  time.Duration
+	manual   bool
+	requests int
+}
+
+// NewServer starts serving the scenario on a random local port, its clock starting now
+func NewServer(scenario Scenario) *Server {
+	s := newServer(scenario)
+
+	s.server = httptest.NewServer(s.handler())
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Handler serves the scenario without listening, for mounting it on a server of your own
+func Handler(scenario Scenario) http.Handler {
+	return newServer(scenario).handler()
+}
+
+func newServer(scenario Scenario) *Server {
+	return &Server{
+		scenario: scenario,
+		started:  time.Now(),
+	}
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/president.json", s.handle)
+	mux.HandleFunc("/counties/", s.handleCounties)
+
+	return mux
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Advance moves the scenario clock forward. The clock stops following real time once it's been advanced,
+// so scenarios play out deterministically.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.manual {
+		s.elapsed = time.Since(s.started)
+		s.manual = true
+	}
+
+	s.elapsed += d
+}
+
+// Elapsed is how far into the scenario the server is
+func (s *Server) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now()
+}
+
+// Requests is how many times the scenario was requested
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// now is the scenario clock, the lock has to be held
+func (s *Server) now() time.Duration {
+	if s.manual {
+		return s.elapsed
+	}
+
+	return time.Since(s.started)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	step, data, _ := s.scenario.at(s.now())
+	s.mu.Unlock()
+
+	serve(w, r, step, data)
+}
+
+// handleCounties serves /counties/<state>.json. States the scenario doesn't report have no counties in their file.
+func (s *Server) handleCounties(w http.ResponseWriter, r *http.Request) {
+	state := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/counties/"), ".json")
+
+	s.mu.Lock()
+	s.requests++
+	step, _, counties := s.scenario.at(s.now())
+	s.mu.Unlock()
+
+	data, ok := counties[state]
+	if !ok {
+		data = &scraper.NPRStateData{}
+	}
+
+	serve(w, r, step, data)
+}
+
+// serve answers with data the way step says to
+func serve(w http.ResponseWriter, r *http.Request, step Step, data *scraper.NPRStateData) {
+	if step.Status != 0 {
+		http.Error(w, http.StatusText(step.Status), step.Status)
+		return
+	}
+
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := json.Marshal(data)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if step.Truncate {
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// at returns the step in effect at elapsed, and the data and county files it serves
+func (sc Scenario) at(elapsed time.Duration) (Step, *scraper.NPRStateData, map[string]*scraper.NPRStateData) {
+	var current Step
+	var data *scraper.NPRStateData
+	counties := make(map[string]*scraper.NPRStateData)
+
+	for _, step := range sc.Steps {
+		if step.At > elapsed {
+			break
+		}
+
+		current = step
+
+		if step.Data != nil {
+			data = step.Data
+		}
+
+		for state, file := range step.Counties {
+			counties[state] = file
+		}
+	}
+
+	return current, data, counties
+}
@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RCTabSummary is the round by round summary the RCTab tabulator publishes, the format Maine releases its ranked choice results in
+type RCTabSummary struct {
+	Config struct {
+		Contest      string
+		Jurisdiction string
+		Office       string
+	}
+
+	Results []RCTabRound
+}
+
+// RCTabRound is a round of the summary
+type RCTabRound struct {
+	Round int
+
+	// Tally is the votes of every continuing candidate, keyed by name
+	Tally map[string]rctabCount
+
+	// TallyResults are the candidates eliminated or elected after the round, and where their ballots went
+	TallyResults []struct {
+		Eliminated string
+		Elected    string
+		Transfers  map[string]rctabCount
+	}
+}
+
+// rctabExhausted is the transfer target of ballots without a continuing choice left
+const rctabExhausted = "exhausted"
+
+// rctabCount is a vote count, which RCTab writes as a string and sometimes with decimals for fractional transfers
+type rctabCount int64
+
+func (c *rctabCount) UnmarshalJSON(raw []byte) error {
+	text := string(bytes.Trim(raw, `"`))
+
+	value, err := strconv.ParseFloat(text, 64)
+
+	if err != nil {
+		return fmt.Errorf("%s is not a vote count", raw)
+	}
+
+	*c = rctabCount(value)
+
+	return nil
+}
+
+// ParseRCTab reads an RCTab summary JSON into a contest of the state
+func ParseRCTab(r io.Reader, state election.State) (election.RCVContest, error) {
+	var summary RCTabSummary
+
+	if err := json.NewDecoder(r).Decode(&summary); err != nil {
+		return election.RCVContest{}, fmt.Errorf("not an RCTab summary: %w", err)
+	}
+
+	return summary.Transform(state)
+}
+
+// Transform transforms the summary to our data format
+func (s *RCTabSummary) Transform(state election.State) (election.RCVContest, error) {
+	if len(s.Results) == 0 {
+		return election.RCVContest{}, fmt.Errorf("the summary of %q has no rounds", s.Config.Contest)
+	}
+
+	contest := election.RCVContest{
+		Name:   s.Config.Contest,
+		State:  state,
+		Office: s.Config.Office,
+	}
+
+	// RCTab names candidates "Last, First"
+	candidate := func(name string) election.Candidate {
+		parts := strings.SplitN(name, ",", 2)
+
+		if len(parts) == 1 {
+			return election.Candidate{LastName: name}
+		}
+
+		return election.Candidate{
+			FirstName: strings.TrimSpace(parts[1]),
+			LastName:  strings.TrimSpace(parts[0]),
+		}
+	}
+
+	rounds := append([]RCTabRound(nil), s.Results...)
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].Round < rounds[j].Round
+	})
+
+	var exhausted int64
+	for _, r := range rounds {
+		round := election.RCVRound{
+			Number:    r.Round,
+			Exhausted: exhausted,
+		}
+
+		for name, votes := range r.Tally {
+			round.Tallies = append(round.Tallies, election.RCVTally{
+				Candidate: candidate(name),
+				Votes:     int64(votes),
+			})
+		}
+
+		// names first so ties come out the same every time, SortTallies keeps them in that order
+		sort.Slice(round.Tallies, func(i, j int) bool {
+			return round.Tallies[i].Candidate.LastName < round.Tallies[j].Candidate.LastName
+		})
+
+		for _, result := range r.TallyResults {
+			if result.Eliminated == "" {
+				continue
+			}
+
+			from := candidate(result.Eliminated)
+			round.Eliminated = append(round.Eliminated, from)
+
+			transfers := make([]election.RCVTransfer, 0, len(result.Transfers))
+			for to, votes := range result.Transfers {
+				transfer := election.RCVTransfer{
+					From:  from,
+					Votes: int64(votes),
+				}
+
+				if strings.EqualFold(to, rctabExhausted) {
+					transfer.Exhausted = true
+					exhausted += int64(votes)
+				} else {
+					transfer.To = candidate(to)
+				}
+
+				transfers = append(transfers, transfer)
+			}
+
+			// most votes first, so the transfer path reads from where most of the ballots went
+			sort.SliceStable(transfers, func(i, j int) bool {
+				return transfers[i].Votes > transfers[j].Votes
+			})
+
+			round.Transfers = append(round.Transfers, transfers...)
+		}
+
+		contest.Rounds = append(contest.Rounds, round)
+	}
+
+	contest.SortTallies()
+
+	return contest, nil
+}
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// me-cd2-2018.json is Maine's 2nd district in 2018, the first congressional race decided by ranked choice.
+// The round totals are the official ones, how the transfers split between Bond and Hoar is made up.
+func TestParseRCTab(t *testing.T) {
+	f, err := os.Open("testdata/me-cd2-2018.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	maine := election.State{Name: "Maine", Abbreviation: "ME"}
+
+	contest, err := ParseRCTab(f, maine)
+	if err != nil {
+		t.Fatalf("could not parse the summary: %v", err)
+	}
+
+	if contest.Name != "Representative to Congress District 2" || contest.Office != "Representative to Congress" || contest.State != maine {
+		t.Errorf("parsed the contest as %q for %q in %s", contest.Name, contest.Office, contest.State.Abbreviation)
+	}
+
+	if len(contest.Rounds) != 2 {
+		t.Fatalf("expected 2 rounds, got %d", len(contest.Rounds))
+	}
+
+	golden := election.Candidate{FirstName: "Jared F.", LastName: "Golden"}
+	poliquin := election.Candidate{FirstName: "Bruce", LastName: "Poliquin"}
+	bond := election.Candidate{FirstName: "Tiffany L.", LastName: "Bond"}
+	hoar := election.Candidate{FirstName: "William R.S.", LastName: "Hoar"}
+
+	first := contest.Rounds[0]
+
+	expectTallies(t, first, []election.RCVTally{
+		{Candidate: poliquin, Votes: 134184},
+		{Candidate: golden, Votes: 132013},
+		{Candidate: bond, Votes: 16552},
+		{Candidate: hoar, Votes: 6875},
+	})
+
+	if first.Exhausted != 0 {
+		t.Errorf("%d ballots exhausted before any transfer", first.Exhausted)
+	}
+
+	if !reflect.DeepEqual(first.Eliminated, []election.Candidate{bond, hoar}) {
+		t.Errorf("eliminated %+v after the first round, expected Bond and Hoar", first.Eliminated)
+	}
+
+	// most votes first for every eliminated candidate
+	expected := []election.RCVTransfer{
+		{From: bond, To: golden, Votes: 7800},
+		{From: bond, Exhausted: true, Votes: 5602},
+		{From: bond, To: poliquin, Votes: 3150},
+		{From: hoar, Exhausted: true, Votes: 2651},
+		{From: hoar, To: golden, Votes: 2627},
+		{From: hoar, To: poliquin, Votes: 1597},
+	}
+
+	if !reflect.DeepEqual(first.Transfers, expected) {
+		t.Errorf("transfers are\n%+v\nexpected\n%+v", first.Transfers, expected)
+	}
+
+	final := contest.Rounds[1]
+
+	expectTallies(t, final, []election.RCVTally{
+		{Candidate: golden, Votes: 142440},
+		{Candidate: poliquin, Votes: 138931},
+	})
+
+	if final.Exhausted != 8253 {
+		t.Errorf("%d ballots exhausted by the final round, expected 8253", final.Exhausted)
+	}
+
+	if len(final.Eliminated) != 0 || len(final.Transfers) != 0 {
+		t.Errorf("the final round eliminated %+v", final.Eliminated)
+	}
+
+	if winner, ok := contest.Winner(); !ok || winner.Key() != golden.Key() {
+		t.Errorf("won by %+v, expected Golden", winner)
+	}
+
+	if !contest.Overturned() {
+		t.Error("Golden trailed Poliquin in first choices, the result is overturned")
+	}
+}
+
+func expectTallies(t *testing.T, round election.RCVRound, expected []election.RCVTally) {
+	t.Helper()
+
+	if !reflect.DeepEqual(round.Tallies, expected) {
+		t.Errorf("round %d tallies are %+v, expected %+v", round.Number, round.Tallies, expected)
+	}
+}
+
+func TestParseRCTabCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		votes   int64
+		problem string
+	}{
+		{name: "string", summary: `{"results": [{"round": 1, "tally": {"Golden": "142440"}}]}`, votes: 142440},
+		{name: "number", summary: `{"results": [{"round": 1, "tally": {"Golden": 142440}}]}`, votes: 142440},
+		{name: "fractional transfer", summary: `{"results": [{"round": 1, "tally": {"Golden": "142440.6"}}]}`, votes: 142440},
+		{name: "not a count", summary: `{"results": [{"round": 1, "tally": {"Golden": "many"}}]}`, problem: "not a vote count"},
+		{name: "no rounds", summary: `{"config": {"contest": "District 2"}, "results": []}`, problem: "has no rounds"},
+		{name: "not json", summary: `<html>`, problem: "not an RCTab summary"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contest, err := ParseRCTab(strings.NewReader(test.summary), election.State{Abbreviation: "ME"})
+
+			if test.problem != "" {
+				if err == nil || !strings.Contains(err.Error(), test.problem) {
+					t.Errorf("expected %q, got %v", test.problem, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if votes := contest.Rounds[0].Tallies[0].Votes; votes != test.votes {
+				t.Errorf("parsed %d votes, expected %d", votes, test.votes)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"github.com/aaomidi/uselections-2020/election"
 )
 
+// ErrNotModified is returned by Scrape when upstream has nothing new since the last scrape
+var ErrNotModified = errors.New("upstream data not modified")
+
 type Scraper interface {
-	Scrape(context context.Context) <-chan election.Vote
+	// Scrape fetches the latest results. The returned channel is closed once every vote was sent.
+	Scrape(context context.Context) (<-chan election.Vote, error)
 }
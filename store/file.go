@@ -0,0 +1,248 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/aaomidi/uselections-2020/election"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is a Memory store that is written to a JSON file after every change.
+// The history and the audit log are appended to files next to it, one JSON value per line.
+// It's meant for single node deployments that don't want to run Redis.
+type File struct {
+	*Memory
+	path    string
+	flushMu sync.Mutex
+	log     *log.Entry
+
+	// logMu serialises appends to the history and audit files
+	logMu sync.Mutex
+}
+
+func NewFile(path string) (*File, error) {
+	f := &File{
+		Memory: NewMemory(),
+		path:   path,
+		log:    log.WithField("source", "store"),
+	}
+
+	if err := f.loadLogs(); err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		f.log.Infof("starting with an empty store at %s", path)
+		return f, nil
+	}
+
+	if err != nil {
+		return nil, NewError(err, "unable to read store file")
+	}
+
+	if err := json.Unmarshal(raw, &f.data); err != nil {
+		return nil, NewError(err, "store file is not valid json")
+	}
+
+	if f.data.Messages == nil {
+		f.data.Messages = make(map[string]int)
+	}
+
+	if f.data.Summaries == nil {
+		f.data.Summaries = make(map[int64]int)
+	}
+
+	if f.data.Inline == nil {
+		f.data.Inline = make(map[string][]string)
+	}
+
+	f.log.Infof("loaded store from %s", path)
+
+	return f, nil
+}
+
+func (f *File) SaveMessageIdForState(channelId int64, state string, messageId int) error {
+	if err := f.Memory.SaveMessageIdForState(channelId, state, messageId); err != nil {
+		return err
+	}
+
+	return f.flush()
+}
+
+func (f *File) SaveSummaryMessageId(channelId int64, messageId int) error {
+	if err := f.Memory.SaveSummaryMessageId(channelId, messageId); err != nil {
+		return err
+	}
+
+	return f.flush()
+}
+
+func (f *File) SaveInlineMessageId(state string, inlineMessageId string) error {
+	if err := f.Memory.SaveInlineMessageId(state, inlineMessageId); err != nil {
+		return err
+	}
+
+	return f.flush()
+}
+
+func (f *File) RemoveInlineMessageId(state string, msgId string) error {
+	if err := f.Memory.RemoveInlineMessageId(state, msgId); err != nil {
+		return err
+	}
+
+	return f.flush()
+}
+
+func (f *File) historyPath() string {
+	return f.path + ".history"
+}
+
+func (f *File) auditPath() string {
+	return f.path + ".audit"
+}
+
+// loadLogs reads the append only files back into memory
+func (f *File) loadLogs() error {
+	err := readLines(f.historyPath(), func(decoder *json.Decoder) error {
+		var snapshot election.Snapshot
+
+		if err := decoder.Decode(&snapshot); err != nil {
+			return err
+		}
+
+		f.history = append(f.history, snapshot)
+		return nil
+	})
+
+	if err != nil {
+		return NewError(err, "unable to read history file")
+	}
+
+	err = readLines(f.auditPath(), func(decoder *json.Decoder) error {
+		var entry AuditEntry
+
+		if err := decoder.Decode(&entry); err != nil {
+			return err
+		}
+
+		f.audit = append(f.audit, entry)
+		return nil
+	})
+
+	if err != nil {
+		return NewError(err, "unable to read audit file")
+	}
+
+	return nil
+}
+
+func (f *File) SaveSnapshot(snapshot election.Snapshot) error {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	if err := appendLine(f.historyPath(), snapshot); err != nil {
+		return NewError(err, "unable to append to history file")
+	}
+
+	return f.Memory.SaveSnapshot(snapshot)
+}
+
+func (f *File) AppendAudit(entry AuditEntry) error {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	if err := appendLine(f.auditPath(), entry); err != nil {
+		return NewError(err, "unable to append to audit file")
+	}
+
+	return f.Memory.AppendAudit(entry)
+}
+
+// readLines calls read until every JSON value in the file was decoded. A missing file has none.
+func readLines(path string, read func(decoder *json.Decoder) error) error {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		if err := read(decoder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendLine appends v to the file as a line of JSON
+func appendLine(path string, v interface{}) error {
+	raw, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(raw, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// flush writes the store to a temporary file and renames it over the old one,
+// so a crash mid-write never leaves a half written store behind.
+func (f *File) flush() error {
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+
+	f.mu.RLock()
+	raw, err := json.Marshal(f.data)
+	f.mu.RUnlock()
+
+	if err != nil {
+		return NewError(err, "unable to encode store")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+
+	if err != nil {
+		return NewError(err, "unable to create temporary store file")
+	}
+
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return NewError(err, "unable to write store file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return NewError(err, "unable to write store file")
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return NewError(err, "unable to replace store file")
+	}
+
+	return nil
+}
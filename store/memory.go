@@ -0,0 +1,178 @@
+package store
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contents is the whole state of a Memory store. It doubles as the on-disk format of File.
+type contents struct {
+	Messages  map[string]int      `json:"messages"`
+	Summaries map[int64]int       `json:"summaries"`
+	Inline    map[string][]string `json:"inline"`
+}
+
+func newContents() contents {
+	return contents{
+		Messages:  make(map[string]int),
+		Summaries: make(map[int64]int),
+		Inline:    make(map[string][]string),
+	}
+}
+
+// Memory is a Store that only lives as long as the process. Useful for tests and dry runs.
+type Memory struct {
+	mu      sync.RWMutex
+	data    contents
+	history []election.Snapshot
+	audit   []AuditEntry
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		data: newContents(),
+	}
+}
+
+func (m *Memory) GetMessageIdForState(channelId int64, state string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messageId, ok := m.data.Messages[stateKey(channelId, state)]
+
+	if !ok {
+		return 0, NewError(ErrNotFound, "no message id for state")
+	}
+
+	return messageId, nil
+}
+
+func (m *Memory) SaveMessageIdForState(channelId int64, state string, messageId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data.Messages[stateKey(channelId, state)] = messageId
+
+	return nil
+}
+
+func (m *Memory) GetSummaryMessageId(channelId int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messageId, ok := m.data.Summaries[channelId]
+
+	if !ok {
+		return 0, NewError(ErrNotFound, "no summary message id for channel")
+	}
+
+	return messageId, nil
+}
+
+func (m *Memory) SaveSummaryMessageId(channelId int64, messageId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data.Summaries[channelId] = messageId
+
+	return nil
+}
+
+func (m *Memory) SaveInlineMessageId(state string, inlineMessageId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToUpper(state)
+	m.data.Inline[key] = append(m.data.Inline[key], inlineMessageId)
+
+	return nil
+}
+
+func (m *Memory) GetInlineMessageId(state string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := m.data.Inline[strings.ToUpper(state)]
+	result := make([]string, len(ids))
+	copy(result, ids)
+
+	return result, nil
+}
+
+func (m *Memory) RemoveInlineMessageId(state string, msgId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToUpper(state)
+	ids := m.data.Inline[key]
+	kept := ids[:0]
+
+	for _, id := range ids {
+		if id != msgId {
+			kept = append(kept, id)
+		}
+	}
+
+	m.data.Inline[key] = kept
+
+	return nil
+}
+
+func (m *Memory) SaveSnapshot(snapshot election.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = append(m.history, snapshot)
+
+	return nil
+}
+
+func (m *Memory) GetSnapshots(from time.Time, to time.Time) ([]election.Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]election.Snapshot, 0)
+	for _, snapshot := range m.history {
+		if InRange(snapshot.Time, from, to) {
+			result = append(result, snapshot)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *Memory) GetLatestSnapshot() (election.Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.history) == 0 {
+		return election.Snapshot{}, NewError(ErrNotFound, "no snapshots recorded")
+	}
+
+	return m.history[len(m.history)-1], nil
+}
+
+func (m *Memory) AppendAudit(entry AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.audit = append(m.audit, entry)
+
+	return nil
+}
+
+func (m *Memory) GetAudit(state string, from time.Time, to time.Time) ([]AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]AuditEntry, 0)
+	for _, entry := range m.audit {
+		if entry.Matches(state, from, to) {
+			result = append(result, entry)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a lookup has nothing stored for the given key
+var ErrNotFound = errors.New("not found")
+
+// Store is everything the bot needs to persist between restarts.
+// redis.Redis, Memory and File all implement it.
+type Store interface {
+	GetMessageIdForState(channelId int64, state string) (int, error)
+	SaveMessageIdForState(channelId int64, state string, messageId int) error
+
+	GetSummaryMessageId(channelId int64) (int, error)
+	SaveSummaryMessageId(channelId int64, messageId int) error
+
+	SaveInlineMessageId(state string, inlineMessageId string) error
+	GetInlineMessageId(state string) ([]string, error)
+	RemoveInlineMessageId(state string, msgId string) error
+
+	// SaveSnapshot appends a snapshot to the history
+	SaveSnapshot(snapshot election.Snapshot) error
+	// GetSnapshots returns the history between from and to, oldest first. A zero time leaves that end open.
+	GetSnapshots(from time.Time, to time.Time) ([]election.Snapshot, error)
+	// GetLatestSnapshot returns the newest snapshot, or ErrNotFound when there's no history yet
+	GetLatestSnapshot() (election.Snapshot, error)
+
+	// AppendAudit adds an entry to the audit log, which is never rewritten
+	AppendAudit(entry AuditEntry) error
+	// GetAudit returns the audit log of a state between from and to, oldest first. An empty state means every state.
+	GetAudit(state string, from time.Time, to time.Time) ([]AuditEntry, error)
+}
+
+// SummaryAudit is the state the edits of the pinned summary are audited under
+const SummaryAudit = "SUMMARY"
+
+// AuditEntry is a single state update the bot published, and where it went
+type AuditEntry struct {
+	Time  time.Time
+	State string
+
+	// TextHash is the SHA-256 of the rendered message, hex encoded
+	TextHash string
+
+	// Counts are the votes of every candidate in the message, keyed by last name
+	Counts              map[string]int64
+	TotalVotes          int64
+	ReportingPercentage float64
+
+	Targets []AuditTarget
+}
+
+// AuditTarget is a message an update was edited into
+type AuditTarget struct {
+	MessageID string
+
+	// Error is why the edit failed, empty when it went through
+	Error string
+
+	// Unchanged is set when Telegram skipped the edit because the message already said the same
+	Unchanged bool
+}
+
+// Matches reports whether the entry is of the state, or of any state when it's empty, and within from and to
+func (e AuditEntry) Matches(state string, from time.Time, to time.Time) bool {
+	if state != "" && !strings.EqualFold(state, e.State) {
+		return false
+	}
+
+	return InRange(e.Time, from, to)
+}
+
+// InRange reports whether t is between from and to, where a zero time leaves that end open
+func InRange(t time.Time, from time.Time, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+
+	return true
+}
+
+func stateKey(channelId int64, state string) string {
+	return fmt.Sprintf("%d-%s", channelId, strings.ToUpper(state))
+}
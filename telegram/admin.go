@@ -0,0 +1,196 @@
+package telegram
+
+import (
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"html"
+	"strings"
+	"time"
+)
+
+// handleAdmin registers a command that only the configured admins may run
+func (t *Telegram) handleAdmin(command string, handler func(m *tb.Message)) {
+	t.bot.Handle(command, func(m *tb.Message) {
+		if m.Sender == nil || !t.isAdmin(m.Sender.ID) {
+			t.log.Warnf("ignoring %s from non admin %v", command, m.Sender)
+			return
+		}
+
+		t.log.Infof("admin %d ran %s", m.Sender.ID, command)
+		handler(m)
+	})
+}
+
+func (t *Telegram) isAdmin(id int) bool {
+	for _, admin := range t.settings.Admins {
+		if admin == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Telegram) reply(m *tb.Message, text string) {
+	if _, err := t.bot.Send(m.Sender, text, tb.ModeHTML); err != nil {
+		t.log.WithError(err).Warn("failed replying to admin")
+	}
+}
+
+func (t *Telegram) handleStatus(m *tb.Message) {
+	p := getPrinter()
+	now := time.Now()
+	status := "<b>Status</b>\n"
+
+	for _, source := range t.data.Status() {
+		status += p.Sprintf("\n<b>%s</b>\n\tlast scrape: %s\n\tlast change: %s\n\tnext scrape: %s\n",
+			source.Name, getAge(now, source.LastScrape), getAge(now, source.LastChange), source.NextScrape.Sub(now).Round(time.Second))
+
+		if source.LastError != nil {
+			status += p.Sprintf("\tlast error: %s\n", source.LastError)
+		}
+	}
+
+	var updated time.Time
+	t.latestMu.RLock()
+	for _, vote := range t.latest {
+		if vote.dem.StateVote.Updated.After(updated) {
+			updated = vote.dem.StateVote.Updated
+		}
+	}
+	t.latestMu.RUnlock()
+
+	status += p.Sprintf("\nsource updated: %s\nedit queue: %d/%d\n", getAge(now, updated), len(t.dataChannel), cap(t.dataChannel))
+
+	status += "\n<b>Inline messages</b>\n"
+	for _, state := range election.GetStates() {
+		ids, err := t.store.GetInlineMessageId(state.Abbreviation)
+
+		if err != nil {
+			status += p.Sprintf("%s: %s\n", state.Abbreviation, err)
+			continue
+		}
+
+		paused := ""
+		if t.isPaused(state.Abbreviation) {
+			paused = " (paused)"
+		}
+
+		status += p.Sprintf("%s: %d%s\n", state.Abbreviation, len(ids), paused)
+	}
+
+	t.reply(m, status)
+}
+
+func (t *Telegram) handleAccept(m *tb.Message) {
+	t.data.Accept()
+	t.reply(m, "Publishing the quarantined results")
+}
+
+// alertAdmins sends a message to every admin directly
+func (t *Telegram) alertAdmins(text string) {
+	for _, admin := range t.settings.Admins {
+		if _, err := t.bot.Send(&tb.User{ID: admin}, text, tb.ModeHTML); err != nil {
+			t.log.WithError(err).Warnf("failed alerting admin %d", admin)
+		}
+	}
+}
+
+func getAnomalyAlert(anomalies []data.Anomaly) string {
+	alert := "⚠️ <b>Held back the latest results</b>\n\n"
+
+	for _, anomaly := range anomalies {
+		alert += html.EscapeString(anomaly.String()) + "\n"
+	}
+
+	return alert + "\nThe channel keeps the previous numbers. /accept publishes these anyway."
+}
+
+func (t *Telegram) handleRefresh(m *tb.Message) {
+	t.data.Refresh()
+	t.reply(m, "Scraping now")
+}
+
+func (t *Telegram) handlePause(m *tb.Message) {
+	t.setPaused(m, true)
+}
+
+func (t *Telegram) handleResume(m *tb.Message) {
+	t.setPaused(m, false)
+}
+
+// setPaused pauses or resumes the edits of the states given as the command's arguments, or of every state with "all"
+func (t *Telegram) setPaused(m *tb.Message, paused bool) {
+	args := strings.Fields(strings.ToUpper(m.Payload))
+
+	if len(args) == 0 {
+		t.reply(m, "Which states? Give their abbreviations, or all")
+		return
+	}
+
+	if len(args) == 1 && args[0] == "ALL" {
+		args = args[:0]
+		for _, state := range election.GetStates() {
+			args = append(args, state.Abbreviation)
+		}
+	}
+
+	t.pausedMu.Lock()
+	for _, state := range args {
+		if !election.StateExists(state) {
+			t.pausedMu.Unlock()
+			t.reply(m, "Unknown state "+state)
+			return
+		}
+	}
+
+	for _, state := range args {
+		t.paused[state] = paused
+	}
+	t.pausedMu.Unlock()
+
+	verb := "Resumed"
+	if paused {
+		verb = "Paused"
+	}
+
+	t.reply(m, verb+" "+strings.Join(args, ", "))
+}
+
+func (t *Telegram) isPaused(state string) bool {
+	t.pausedMu.RLock()
+	defer t.pausedMu.RUnlock()
+
+	return t.paused[state]
+}
+
+func (t *Telegram) handleAnnounce(m *tb.Message) {
+	if strings.TrimSpace(m.Payload) == "" {
+		t.reply(m, "Nothing to announce")
+		return
+	}
+
+	for _, channel := range t.getChannels() {
+		if _, err := t.bot.Send(channel, m.Payload); err != nil {
+			t.log.WithError(err).Warnf("failed announcing to %s", channel.Recipient())
+			t.reply(m, "Failed announcing to "+channel.Recipient()+": "+err.Error())
+			continue
+		}
+	}
+
+	t.reply(m, "Announced")
+}
+
+// getChannels returns every channel the bot posts to
+func (t *Telegram) getChannels() []*tb.Chat {
+	return []*tb.Chat{t.channel}
+}
+
+func getAge(now time.Time, then time.Time) string {
+	if then.IsZero() {
+		return "never"
+	}
+
+	return now.Sub(then).Round(time.Second).String() + " ago"
+}
@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/aaomidi/uselections-2020/store"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"strings"
+	"time"
+)
+
+// newAuditEntry records what an update of a state said, before it's edited into any message
+func newAuditEntry(state string, text string, vote *StateVote) store.AuditEntry {
+	hash := sha256.Sum256([]byte(text))
+
+	return store.AuditEntry{
+		Time:     time.Now(),
+		State:    state,
+		TextHash: hex.EncodeToString(hash[:]),
+		Counts: map[string]int64{
+			vote.dem.Candidate.LastName: vote.dem.Count,
+			vote.rep.Candidate.LastName: vote.rep.Count,
+		},
+		TotalVotes:          vote.dem.StateVote.TotalVotes,
+		ReportingPercentage: vote.dem.StateVote.ReportingPercentage,
+	}
+}
+
+// newSummaryAuditEntry records what an edit of the pinned summary said
+func newSummaryAuditEntry(text string) store.AuditEntry {
+	hash := sha256.Sum256([]byte(text))
+
+	return store.AuditEntry{
+		Time:     time.Now(),
+		State:    store.SummaryAudit,
+		TextHash: hex.EncodeToString(hash[:]),
+	}
+}
+
+// newAuditTarget records how editing a message went. Inline edits answer with ErrTrueResult when they work.
+func newAuditTarget(messageId string, err error) store.AuditTarget {
+	target := store.AuditTarget{
+		MessageID: messageId,
+	}
+
+	switch {
+	case err == nil || errors.Is(err, tb.ErrTrueResult):
+	case isNotModified(err):
+		target.Unchanged = true
+	default:
+		target.Error = err.Error()
+	}
+
+	return target
+}
+
+// isNotModified reports whether Telegram refused an edit only because the text is the same as before
+func isNotModified(err error) bool {
+	return errors.Is(err, tb.ErrMessageNotModified) || strings.Contains(err.Error(), "message is not modified")
+}
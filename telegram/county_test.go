@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"testing"
+)
+
+func TestGetCountyBlock(t *testing.T) {
+	dem := election.Candidate{LastName: "Biden", Party: election.Party{Abbreviation: "Dem"}}
+	rep := election.Candidate{LastName: "Trump", Party: election.Party{Abbreviation: "GOP"}}
+	independent := election.Candidate{LastName: "West"}
+
+	vote := &StateVote{
+		counties: []election.CountyDelta{
+			{County: election.County{Name: "Philadelphia"}, Votes: 40123, Leader: dem, LeaderVotes: 30092},
+			{County: election.County{Name: "Allegheny"}, Votes: 1250000, Leader: rep, LeaderVotes: 700000},
+			{County: election.County{Name: "Erie"}, Votes: 812, Leader: independent, LeaderVotes: 406},
+			{County: election.County{Name: "Dauphin"}, Votes: 100, Leader: dem, LeaderVotes: 60},
+		},
+	}
+
+	want := "+40k votes from Philadelphia, 75% Dem\n" +
+		"+1.2M votes from Allegheny, 56% GOP\n" +
+		"+812 votes from Erie, 50% West\n"
+
+	if got := getCountyBlock(vote); got != want {
+		t.Errorf("got\n%s\nexpected\n%s", got, want)
+	}
+
+	if got := getCountyBlock(&StateVote{}); got != "" {
+		t.Errorf("a state without a batch got %q", got)
+	}
+}
+
+func TestGetShortCount(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0",
+		999:     "999",
+		1000:    "1k",
+		40499:   "40k",
+		1000000: "1.0M",
+		2460000: "2.5M",
+	}
+
+	for count, want := range tests {
+		if got := getShortCount(count); got != want {
+			t.Errorf("getShortCount(%d) = %q, expected %q", count, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"github.com/aaomidi/uselections-2020/election"
+	"time"
+)
+
+// loadFirstCounts reads the first count of every state out of the history, so blue shifts survive a restart
+func (t *Telegram) loadFirstCounts() map[string]election.FirstCount {
+	history, err := t.store.GetSnapshots(time.Time{}, time.Time{})
+
+	if err != nil {
+		t.log.WithError(err).Warn("could not read the history, blue shifts start from the next count")
+		return make(map[string]election.FirstCount)
+	}
+
+	return election.GetFirstCounts(history)
+}
+
+// getMailLine breaks the votes down by how they were cast, "📬 Mail 2.1M / in person 3.0M, 🐴 58% 🐘 27% by mail"
+func getMailLine(vote *StateVote) string {
+	results := vote.dem.StateVote
+
+	if results.AbsenteeVotes == 0 {
+		return ""
+	}
+
+	return getPrinter().Sprintf("📬 Mail %s / in person %s, %s %.0f%% %s %.0f%% by mail\n",
+		getShortCount(results.AbsenteeVotes), getShortCount(results.InPersonVotes()),
+		vote.dem.Candidate.Party.Symbol, vote.dem.MailShare()*100,
+		vote.rep.Candidate.Party.Symbol, vote.rep.MailShare()*100)
+}
+
+// getShiftLine shows how the margin moved since the first count, "↔️ Shift D+3.2 since 21:04, 64% of the 1.2M since by mail"
+func getShiftLine(vote *StateVote) string {
+	if vote.shift == nil {
+		return ""
+	}
+
+	shift := vote.shift
+	line := getPrinter().Sprintf("↔️ Shift %s since %s", getMargin(shift.Shift()), getFormattedTimeOf(shift.Since))
+
+	if shift.AddedMail > 0 {
+		line += getPrinter().Sprintf(", %.0f%% of the %s since by mail", shift.AddedMailShare()*100, getShortCount(shift.AddedVotes))
+	}
+
+	return line + "\n"
+}
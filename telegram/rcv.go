@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"html"
+	"sort"
+	"strings"
+)
+
+// PublishRCV posts the tabulation of a ranked choice contest to the channel
+func (t *Telegram) PublishRCV(contest election.RCVContest) error {
+	if _, err := t.bot.Send(t.channel, GetRCVMessage(contest), tb.ModeHTML); err != nil {
+		return NewError(err, "could not send ranked choice results")
+	}
+
+	return nil
+}
+
+// GetRCVMessage renders the first choices, the transfer path through every elimination and the final round
+func GetRCVMessage(contest election.RCVContest) string {
+	first, ok := contest.FirstRound()
+
+	if !ok {
+		return ""
+	}
+
+	final, _ := contest.FinalRound()
+
+	message := fmt.Sprintf("🔢 <b>%s</b>\n%s, ranked choice\n\n", html.EscapeString(contest.Name), contest.State.Name)
+	message += "First choices: " + getRCVTallies(first, 0) + "\n"
+
+	for _, round := range contest.Rounds {
+		if len(round.Eliminated) == 0 {
+			continue
+		}
+
+		message += getRCVTransferLine(round)
+	}
+
+	winner, won := contest.Winner()
+	mark := ""
+	if won {
+		mark = "✅ "
+	}
+
+	message += getPrinter().Sprintf("\nRound %d: %s%s\n", final.Number, mark, getRCVTallies(final, 2))
+
+	if final.Exhausted > 0 {
+		message += getPrinter().Sprintf("%d ballots exhausted\n", final.Exhausted)
+	}
+
+	if won && contest.Overturned() {
+		message += fmt.Sprintf("%s wins after trailing the first choices\n", html.EscapeString(winner.LastName))
+	}
+
+	return message + "\nLast Updated " + getFormattedTime()
+}
+
+// getRCVTallies lists the candidates of a round with their share of the continuing ballots, the votes too
+// for the first ones of them. "Golden 53.0% (142,440), Poliquin 47.0% (138,931)"
+func getRCVTallies(round election.RCVRound, withVotes int) string {
+	parts := make([]string, 0, len(round.Tallies))
+
+	for i, tally := range round.Tallies {
+		part := getPrinter().Sprintf("%s %.1f%%", html.EscapeString(tally.Candidate.LastName), round.Share(tally)*100)
+
+		if i < withVotes {
+			part += getPrinter().Sprintf(" (%d)", tally.Votes)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// getRCVTransferLine is where the ballots of a round's eliminated candidates went, "Bond, Hoar out → Golden +10,427, Poliquin +4,747, 8,253 exhausted"
+func getRCVTransferLine(round election.RCVRound) string {
+	eliminated := make([]string, 0, len(round.Eliminated))
+	for _, candidate := range round.Eliminated {
+		eliminated = append(eliminated, html.EscapeString(candidate.LastName))
+	}
+
+	// a batch elimination transfers to the same candidates from several of them, so they're summed up
+	order := make([]string, 0)
+	received := make(map[string]int64)
+	var exhausted int64
+
+	for _, transfer := range round.Transfers {
+		if transfer.Exhausted {
+			exhausted += transfer.Votes
+			continue
+		}
+
+		name := transfer.To.LastName
+		if _, ok := received[name]; !ok {
+			order = append(order, name)
+		}
+
+		received[name] += transfer.Votes
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return received[order[i]] > received[order[j]]
+	})
+
+	parts := make([]string, 0, len(order)+1)
+	for _, name := range order {
+		parts = append(parts, getPrinter().Sprintf("%s +%d", html.EscapeString(name), received[name]))
+	}
+
+	if exhausted > 0 {
+		parts = append(parts, getPrinter().Sprintf("%d exhausted", exhausted))
+	}
+
+	return fmt.Sprintf("Round %d: %s out → %s\n", round.Number, strings.Join(eliminated, ", "), strings.Join(parts, ", "))
+}
@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/aaomidi/uselections-2020/election"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ensureSummary sends and pins the national summary message if the channel doesn't have one yet
+func (t *Telegram) ensureSummary() error {
+	id, err := t.store.GetSummaryMessageId(t.channel.ID)
+
+	if err == nil && id != 0 {
+		return nil
+	}
+
+	t.log.Info("sending new summary message")
+
+	send, err := t.bot.Send(t.channel, "National summary, updating soon")
+
+	if err != nil {
+		return NewError(err, "could not send summary message")
+	}
+
+	if err := t.store.SaveSummaryMessageId(t.channel.ID, send.ID); err != nil {
+		return NewError(err, "could not save summary message id")
+	}
+
+	if err := t.bot.Pin(send, tb.Silent); err != nil {
+		return NewError(err, "could not pin summary message")
+	}
+
+	return nil
+}
+
+// updateSummary edits the pinned summary to the latest results of every state
+func (t *Telegram) updateSummary(m map[string]*StateVote) {
+	id, err := t.store.GetSummaryMessageId(t.channel.ID)
+
+	if err != nil || id == 0 {
+		return
+	}
+
+	editableMsg := EditableMessage{
+		MsgID:     strconv.Itoa(id),
+		ChannelID: t.channel.ID,
+	}
+
+	text := t.getSummaryMessage(m)
+	entry := newSummaryAuditEntry(text)
+
+	err = t.edit(editableMsg, text, tb.ModeHTML, tb.NoPreview)
+	entry.Targets = append(entry.Targets, t.auditTarget(editableMsg.MsgID, err, "the summary"))
+
+	if err := t.store.AppendAudit(entry); err != nil {
+		t.log.WithError(err).Warn("failed auditing the summary update")
+	}
+}
+
+// getSummaryMessage renders a line per watched state with the leader, margin and reporting, linking to the state's message
+func (t *Telegram) getSummaryMessage(m map[string]*StateVote) string {
+	summary := fmt.Sprintf("<b>%s</b>\nBattleground summary\n\n", html.EscapeString(election.GetName()))
+
+	for _, state := range election.GetStates() {
+		name := state.Abbreviation
+
+		if id, err := t.store.GetMessageIdForState(t.channel.ID, state.Abbreviation); err == nil && id != 0 {
+			name = fmt.Sprintf(`<a href="%s">%s</a>`, getMessageLink(t.channel, id), state.Abbreviation)
+		}
+
+		vote, ok := m[state.Abbreviation]
+
+		if !ok {
+			summary += name + " waiting for results\n"
+			continue
+		}
+
+		summary += getPrinter().Sprintf("%s %s · %.0f%% in\n", name, getLeaderLine(vote), vote.dem.StateVote.ReportingPercentage*100)
+	}
+
+	summary += "\nLast Updated " + getFormattedTime()
+
+	return summary
+}
+
+// getLeaderLine is the leader and their margin, "🐴 Biden +1.20%"
+func getLeaderLine(vote *StateVote) string {
+	leader, trailer := vote.getLeader()
+
+	return getPrinter().Sprintf("%s %s +%.2f%%", leader.Candidate.Party.Symbol, leader.Candidate.LastName, (leader.Percentage-trailer.Percentage)*100)
+}
+
+// getMessageLink deep links to a message of a channel. Public channels link by username,
+// private ones by their ID without the -100 prefix Telegram puts in front of channel IDs.
+func getMessageLink(channel *tb.Chat, messageId int) string {
+	if channel.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", channel.Username, messageId)
+	}
+
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(strconv.FormatInt(channel.ID, 10), "-100"), messageId)
+}
@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"github.com/aaomidi/uselections-2020/data"
 	"github.com/aaomidi/uselections-2020/election"
-	"github.com/aaomidi/uselections-2020/redis"
+	"github.com/aaomidi/uselections-2020/store"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	tb "gopkg.in/tucnak/telebot.v2"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,26 +23,75 @@ type Telegram struct {
 	channelID   string
 	channel     *tb.Chat
 	log         *log.Entry
-	redis       *redis.Redis
+	store       store.Store
 	data        *data.Data
 	dataChannel chan data.OutgoingUpdate
+	settings    Settings
+	webhook     *webhook
+
+	pausedMu sync.RWMutex
+	paused   map[string]bool
+
+	// startMu guards started and stopped, telebot's Stop blocks for good on a bot that never started
+	startMu sync.Mutex
+	started bool
+	stopped bool
+
+	// latest holds a copy of the most recent results of every state, for answering inline queries
+	latestMu sync.RWMutex
+	latest   map[string]StateVote
+}
+
+// Settings are the optional parts of the messages the bot sends
+type Settings struct {
+	// CompareYear adds a line comparing every state to that previous cycle. 0 leaves it out.
+	CompareYear int
+
+	// Admins are the user IDs allowed to run the admin commands
+	Admins []int
+
+	// APIURL is the Bot API server to talk to, the public one when empty
+	APIURL string
+
+	// Webhook receives updates through a webhook rather than long polling, when set
+	Webhook *WebhookSettings
+
+	// UpdateInterval is the least time between two rounds of edits, 20 seconds when 0
+	UpdateInterval time.Duration
 }
 
-func New(token string, channelID string, r *redis.Redis, d *data.Data) Telegram {
+func New(token string, channelID string, s store.Store, d *data.Data, settings Settings) Telegram {
 	return Telegram{
 		token:     token,
 		bot:       nil,
 		channelID: channelID,
 		log:       log.WithField("source", "telegram"),
-		redis:     r,
+		store:     s,
 		data:      d,
+		settings:  settings,
+		latest:    make(map[string]StateVote),
+		paused:    make(map[string]bool),
 	}
 }
 
 func (t *Telegram) Create() error {
+	var poller tb.Poller = &tb.LongPoller{Timeout: 15 * time.Second}
+
+	if t.settings.Webhook != nil {
+		w, err := newWebhook(*t.settings.Webhook, t.log)
+
+		if err != nil {
+			return err
+		}
+
+		t.webhook = w
+		poller = w
+	}
+
 	bot, err := tb.NewBot(tb.Settings{
+		URL:    t.settings.APIURL,
 		Token:  t.token,
-		Poller: &tb.LongPoller{Timeout: 15 * time.Second},
+		Poller: poller,
 	})
 	t.bot = bot
 
@@ -56,6 +107,13 @@ func (t *Telegram) Create() error {
 
 	t.channel = channel
 
+	// registered here rather than once polling starts, so a bot Telegram can't reach fails to start
+	if t.webhook != nil {
+		if err := t.webhook.register(t.bot); err != nil {
+			return err
+		}
+	}
+
 	t.log.Infof("connected to %s", t.channelID)
 
 	return nil
@@ -74,56 +132,98 @@ func (t *Telegram) Start() {
 	// On inline chosen result
 	t.bot.Handle(tb.OnChosenInlineResult, t.handleChosenInlineResult)
 
+	// Admin commands
+	t.handleAdmin("/status", t.handleStatus)
+	t.handleAdmin("/refresh", t.handleRefresh)
+	t.handleAdmin("/accept", t.handleAccept)
+	t.handleAdmin("/pause", t.handlePause)
+	t.handleAdmin("/resume", t.handleResume)
+	t.handleAdmin("/announce", t.handleAnnounce)
+
+	if t.webhook != nil {
+		go func() {
+			t.log.Infof("listening for webhook updates on %s", t.webhook.settings.Listen)
+
+			if err := t.webhook.listen(); err != nil {
+				t.log.WithError(err).Error("webhook listener failed")
+			}
+		}()
+	}
+
+	t.startMu.Lock()
+	if t.stopped {
+		t.startMu.Unlock()
+		return
+	}
+	t.started = true
+	t.startMu.Unlock()
+
 	// Start the bot, listen for queries
 	t.bot.Start()
 }
 
 func (t *Telegram) handleQuery(q *tb.Query) {
-	msg := q.Text
+	states := election.SearchStates(q.Text)
 
-	if len(msg) != 2 {
+	if len(states) == 0 {
 		_ = t.bot.Answer(q, &tb.QueryResponse{
 			Results:   nil,
 			CacheTime: 60,
 		})
 		return
 	}
-	states := election.GetIndexStates()
 
-	state, ok := states[strings.ToUpper(msg)]
+	r := make(tb.Results, 0, len(states))
+	for _, state := range states {
+		r = append(r, t.getStateArticle(state))
+	}
+
+	_ = t.bot.Answer(q, &tb.QueryResponse{
+		Results:   r,
+		CacheTime: 0,
+	})
+}
 
-	if !ok {
-		_ = t.bot.Answer(q, &tb.QueryResponse{
-			Results:   nil,
-			CacheTime: 60,
-		})
-		return
+// getStateArticle renders the latest results of a state as an inline result, so a share has the numbers right away
+func (t *Telegram) getStateArticle(state election.State) *tb.ArticleResult {
+	text := "Updating soon"
+	description := state.Name
+
+	if vote, ok := t.getLatest(state.Abbreviation); ok {
+		text = GetPrettyMessage(&vote, t.settings)
+		description = getLeaderDescription(&vote)
 	}
 
 	article := &tb.ArticleResult{
 		Title:       state.Name,
-		Text:        "Updating soon",
-		Description: state.Name,
+		Description: description,
 	}
 
-	markup := [][]tb.InlineButton{
-		{
-			{
-				Text:        "Share 🔗",
-				InlineQuery: state.Abbreviation,
-			},
-		},
-	}
+	article.SetResultID(state.Abbreviation)
+	article.SetContent(&tb.InputTextMessageContent{
+		Text:      text,
+		ParseMode: tb.ModeHTML,
+	})
+	article.SetReplyMarkup(getShareMarkup(state.Abbreviation))
+
+	return article
+}
 
-	article.SetReplyMarkup(markup)
+// getLatest returns a copy of the latest results of a state
+func (t *Telegram) getLatest(state string) (StateVote, bool) {
+	t.latestMu.RLock()
+	defer t.latestMu.RUnlock()
 
-	r := make(tb.Results, 1)
-	r[0] = article
-	_ = t.bot.Answer(q, &tb.QueryResponse{
-		Results:   r,
-		CacheTime: 0,
-		QueryID:   state.Abbreviation,
-	})
+	vote, ok := t.latest[state]
+	return vote, ok
+}
+
+// getLeaderDescription sums up a state in a line, "Biden leads by 1.20% · 85% reporting"
+func getLeaderDescription(vote *StateVote) string {
+	leader, trailer := vote.getLeader()
+
+	return getPrinter().Sprintf("%s leads by %.2f%% · %.0f%% reporting",
+		leader.Candidate.LastName, (leader.Percentage-trailer.Percentage)*100, leader.StateVote.ReportingPercentage*100)
 }
 
 func getShareMarkup(stateAbbreviation string) [][]tb.InlineButton {
@@ -139,22 +239,47 @@ func getShareMarkup(stateAbbreviation string) [][]tb.InlineButton {
 
 func (t *Telegram) handleChosenInlineResult(c *tb.ChosenInlineResult) {
 	states := election.GetIndexStates()
-	state, ok := states[strings.ToUpper(c.Query)]
+	state, ok := states[strings.ToUpper(c.ResultID)]
 	if !ok {
 		return
 	}
 
-	_ = t.redis.SaveInlineMessageId(state.Abbreviation, c.MessageID)
+	_ = t.store.SaveInlineMessageId(state.Abbreviation, c.MessageID)
 }
 
 func (t *Telegram) Stop() {
-	t.bot.Stop()
+	// the webhook goes first, so Telegram stops pushing to a bot that no longer listens
+	if t.webhook != nil {
+		if err := t.bot.RemoveWebhook(); err != nil {
+			t.log.WithError(err).Warn("failed removing webhook")
+		}
+	}
+
+	t.startMu.Lock()
+	started := t.started
+	t.stopped = true
+	t.startMu.Unlock()
+
+	if started {
+		t.bot.Stop()
+	}
+
+	if t.webhook != nil {
+		if err := t.webhook.shutdown(); err != nil {
+			t.log.WithError(err).Warn("failed shutting down webhook listener")
+		}
+	}
 }
 
 func (t *Telegram) runListener() {
 	go t.runUpdater()
+
+	if err := t.ensureSummary(); err != nil {
+		t.log.WithError(err).Warn("no summary message")
+	}
+
 	for _, s := range election.GetStates() {
-		state, err := t.redis.GetMessageIdForState(t.channel.ID, s.Abbreviation)
+		state, err := t.store.GetMessageIdForState(t.channel.ID, s.Abbreviation)
 
 		if err != nil || state == 0 {
 			t.log.Infof("sending new message for %s", s)
@@ -165,7 +290,7 @@ func (t *Telegram) runListener() {
 				panic(errors.Wrap(err, "cba to deal with this rn"))
 			}
 
-			if err := t.redis.SaveMessageIdForState(t.channel.ID, s.Abbreviation, send.ID); err != nil {
+			if err := t.store.SaveMessageIdForState(t.channel.ID, s.Abbreviation, send.ID); err != nil {
 				panic(errors.Wrap(err, "cba to deal with this rn"))
 			}
 
@@ -177,90 +302,274 @@ func (t *Telegram) runListener() {
 
 }
 
+// updateInterval is the least time between two rounds of edits, so the bot stays clear of Telegram's flood limits
+const updateInterval = 20 * time.Second
+
+// maxEditAttempts is how often an edit is tried while Telegram asks us to slow down,
+// and maxRetryAfter the longest we wait for it before giving up on that edit
+const (
+	maxEditAttempts = 3
+	maxRetryAfter   = 30 * time.Second
+)
+
+var retryAfterRx = regexp.MustCompile(`retry after (\d+)`)
+
 func (t *Telegram) runUpdater() {
 	m := make(map[string]*StateVote)
+	published := make(map[string]StateVote)
+	firsts := t.loadFirstCounts()
 	lastSent := time.Now().Add(-1 * time.Hour)
-	sent := false
-	for {
-		update := <-t.dataChannel
+	lastAlert := ""
 
-		for _, vote := range update.Votes {
-			val, ok := m[vote.State.Abbreviation]
-			if !ok {
-				val = &StateVote{}
-				m[vote.State.Abbreviation] = val
+	interval := t.settings.UpdateInterval
+	if interval == 0 {
+		interval = updateInterval
+	}
+
+	// flush publishes what came in while the edits were throttled, so a change is never held back for good
+	flush := time.NewTimer(time.Hour)
+	flush.Stop()
+	pending := false
+
+	for {
+		select {
+		case <-flush.C:
+			pending = false
+		case update := <-t.dataChannel:
+			if update.Quarantined {
+				// hold the stale messages rather than publish a bad number, and tell the admins once per distinct problem
+				if alert := getAnomalyAlert(update.Anomalies); alert != lastAlert {
+					t.alertAdmins(alert)
+					lastAlert = alert
+				}
+				continue
 			}
+			lastAlert = ""
 
-			if vote.Candidate.Party.Abbreviation == "Dem" {
-				val.dem = vote
+			t.applyUpdate(m, firsts, update)
+
+			if wait := interval - time.Since(lastSent); wait > 0 {
+				if !pending {
+					flush.Reset(wait)
+					pending = true
+				}
+				continue
 			}
 
-			if vote.Candidate.Party.Abbreviation == "GOP" {
-				val.rep = vote
+			if pending {
+				if !flush.Stop() {
+					<-flush.C
+				}
+				pending = false
 			}
 		}
 
-		for _, val := range m {
-			currentTime := time.Now()
+		if len(m) == 0 {
+			continue
+		}
+
+		t.publishStates(m, published)
+		t.updateSummary(m)
+		lastSent = time.Now()
+	}
+}
 
-			if currentTime.Sub(lastSent) < 20*time.Second {
-				continue
-			}
-			sent = true
+// applyUpdate folds a broadcast into the latest results of every state
+func (t *Telegram) applyUpdate(m map[string]*StateVote, firsts map[string]election.FirstCount, update data.OutgoingUpdate) {
+	compared := election.GetComparedParties()
+
+	for _, vote := range update.Votes {
+		val, ok := m[vote.State.Abbreviation]
+		if !ok {
+			val = &StateVote{}
+			m[vote.State.Abbreviation] = val
+		}
 
-			state := val.dem.State.Abbreviation
+		if compared.IsDem(vote.Candidate.Party) {
+			val.dem = vote
+		}
 
-			id, err := t.redis.GetMessageIdForState(t.channel.ID, state)
+		if compared.IsRep(vote.Candidate.Party) {
+			val.rep = vote
+		}
+	}
 
-			if err != nil || id == 0 {
-				continue
+	for state, val := range m {
+		first, ok := firsts[state]
+
+		if !ok {
+			if val.dem.StateVote.TotalVotes > 0 {
+				firsts[state] = election.FirstCount{Time: time.Now(), Dem: val.dem, Rep: val.rep}
 			}
+			continue
+		}
+
+		if shift, ok := election.GetBlueShift(first, val.dem, val.rep); ok {
+			val.shift = &shift
+		}
+	}
+
+	for state, deltas := range update.CountyDeltas {
+		if val, ok := m[state]; ok && len(deltas) > 0 {
+			val.counties = deltas
+		}
+	}
+
+	for state, status := range update.Recounts {
+		if val, ok := m[state]; ok {
+			val.recount = status
+		}
+	}
+
+	t.sendRecountNotifications(update)
+
+	t.latestMu.Lock()
+	for state, val := range m {
+		t.latest[state] = *val
+	}
+	t.latestMu.Unlock()
+}
 
-			editableMsg := EditableMessage{
-				MsgID:     strconv.Itoa(id),
-				ChannelID: t.channel.ID,
+// publishStates edits the latest results into the messages of every state
+func (t *Telegram) publishStates(m map[string]*StateVote, published map[string]StateVote) {
+	for _, val := range m {
+		state := val.dem.State.Abbreviation
+
+		if t.isPaused(state) {
+			continue
+		}
+
+		id, err := t.store.GetMessageIdForState(t.channel.ID, state)
+
+		if err != nil || id == 0 {
+			continue
+		}
+
+		t.log.Infof("sending update for %s", state)
+
+		// a state that didn't move since the previous edit mustn't keep showing the change before it
+		val.since = nil
+		if previous, ok := published[state]; ok {
+			diff := election.Diff([]election.Vote{previous.dem, previous.rep}, []election.Vote{val.dem, val.rep})
+
+			if len(diff) > 0 {
+				val.since = &diff[0]
 			}
+		}
+		published[state] = *val
 
-			t.log.Infof("sending update for %s", state)
-
-			//for i := 0; i < 12; i++ {
-			//	_, err = t.bot.Edit(editableMsg, GetPrettyMessage(val), tb.ModeHTML)
-			//
-			//	if err == nil || strings.Contains(err.Error(), "message is not modified") {
-			//		break
-			//	}
-			//
-			//	if i < 11 && strings.Contains(err.Error(), "Too Many Requests") {
-			//		time.Sleep(time.Second * 5)
-			//	} else {
-			//		t.log.WithError(err).Warnf("failed updating state %s", state)
-			//	}
-			//}
-
-			msgs, err := t.redis.GetInlineMessageId(state)
-
-			if err == nil {
-				for _, msgId := range msgs {
-					editableMsg = EditableMessage{
-						MsgID:     msgId,
-						ChannelID: 0,
-					}
-					_, err = t.bot.Edit(editableMsg, GetPrettyMessage(val), tb.ModeHTML, &tb.ReplyMarkup{InlineKeyboard: getShareMarkup(state)})
-					t.log.WithError(err).Info("Some error happened")
-				}
+		text := GetPrettyMessage(val, t.settings)
+		entry := newAuditEntry(state, text, val)
+
+		channelMsg := EditableMessage{
+			MsgID:     strconv.Itoa(id),
+			ChannelID: t.channel.ID,
+		}
+		err = t.edit(channelMsg, text, tb.ModeHTML)
+		entry.Targets = append(entry.Targets, t.auditTarget(channelMsg.MsgID, err, state))
+
+		msgs, err := t.store.GetInlineMessageId(state)
+
+		if err != nil {
+			t.log.WithError(err).Warnf("failed getting the shared messages of %s", state)
+		}
+
+		for _, msgId := range msgs {
+			inlineMsg := EditableMessage{
+				MsgID:     msgId,
+				ChannelID: 0,
 			}
+			err = t.edit(inlineMsg, text, tb.ModeHTML, &tb.ReplyMarkup{InlineKeyboard: getShareMarkup(state)})
+			entry.Targets = append(entry.Targets, t.auditTarget(msgId, err, state))
+		}
+
+		if err := t.store.AppendAudit(entry); err != nil {
+			t.log.WithError(err).Warnf("failed auditing update for %s", state)
+		}
+
+		// the county batch went out with this edit, the next one only shows counties if a new batch comes in
+		val.counties = nil
+	}
+}
+
+// auditTarget records how an edit of a message went, and logs it when it failed
+func (t *Telegram) auditTarget(msgId string, err error, what string) store.AuditTarget {
+	target := newAuditTarget(msgId, err)
+
+	if target.Error != "" {
+		t.log.WithError(err).Warnf("failed updating %s in message %s", what, msgId)
+	}
+
+	return target
+}
+
+// edit edits a message, waiting as long as Telegram asks when it hits the flood limit
+func (t *Telegram) edit(msg tb.Editable, text string, options ...interface{}) error {
+	for attempt := 1; ; attempt++ {
+		_, err := t.bot.Edit(msg, text, options...)
+
+		wait, limited := getRetryAfter(err)
+		if !limited || attempt == maxEditAttempts || wait > maxRetryAfter {
+			return err
+		}
+
+		t.log.Warnf("flood limited, retrying edit in %s", wait)
+		time.Sleep(wait)
+	}
+}
+
+// getRetryAfter reports whether err is Telegram's flood limit, and how long it asks to wait
+func getRetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	match := retryAfterRx.FindStringSubmatch(err.Error())
+
+	if match == nil {
+		return 0, false
+	}
+
+	seconds, _ := strconv.Atoi(match[1])
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// sendRecountNotifications tells the channel about every state that just entered its recount window
+func (t *Telegram) sendRecountNotifications(update data.OutgoingUpdate) {
+	notified := make(map[string]bool)
+
+	for _, vote := range update.NotificationVotes {
+		state := vote.State.Abbreviation
+		status, ok := update.Recounts[state]
+
+		if !ok || notified[state] {
+			continue
 		}
-		if sent {
-			lastSent = time.Now()
-			sent = false
+		notified[state] = true
+
+		msg := getPrinter().Sprintf("⚖️ <b>%s</b> is inside its %s recount window: %s leads %s by %d votes (%.2f%%)",
+			status.State.Name, getRecountKind(status.Rule), status.Leader.Candidate.LastName, status.RunnerUp.Candidate.LastName,
+			status.Margin, status.MarginPercent*100)
+
+		if _, err := t.bot.Send(t.channel, msg, tb.ModeHTML); err != nil {
+			t.log.WithError(err).Warnf("failed sending recount notification for %s", state)
 		}
 	}
 }
 
+func getRecountKind(rule election.RecountRule) string {
+	if rule.Automatic {
+		return "automatic"
+	}
+
+	return "requestable"
+}
+
 func getPrinter() *message.Printer {
 	return message.NewPrinter(language.English)
 }
-func GetPrettyMessage(vote *StateVote) string {
+func GetPrettyMessage(vote *StateVote, settings Settings) string {
 
 	dem := vote.dem
 	rep := vote.rep
@@ -270,28 +579,150 @@ func GetPrettyMessage(vote *StateVote) string {
 %s
 
 %s State Results
+%s%s
 %s
-%s
-
+%s%s%s%s%s%s
 Last Updated %s
 `,
 
-		getPeekable(vote), dem.State.Name, getCandidateBlock(dem), getCandidateBlock(rep), getFormattedTime())
+		getPeekable(vote), dem.State.Name, getRecountBadge(vote), getCandidateBlock(dem), getCandidateBlock(rep), getSinceLine(vote), getMailLine(vote), getShiftLine(vote), getCountyBlock(vote), getTurnoutLine(vote, settings.CompareYear), getComparisonLine(vote, settings.CompareYear), getFormattedTime())
+}
+
+// getRecountBadge flags states inside their recount window or too close to call
+func getRecountBadge(vote *StateVote) string {
+	switch {
+	case vote.recount.InWindow:
+		return fmt.Sprintf("⚖️ Inside the %s recount window\n", getRecountKind(vote.recount.Rule))
+	case vote.recount.TooClose:
+		return "🔍 Too close to call\n"
+	default:
+		return ""
+	}
+}
+
+// getComparisonLine compares the state to a previous cycle, empty when there's nothing to compare to
+func getComparisonLine(vote *StateVote, year int) string {
+	if year == 0 {
+		return ""
+	}
+
+	c, ok := election.Compare(year, vote.dem, vote.rep)
+
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("vs %d: %s (was %s), shift %s, votes %+.0f%%\n",
+		year, getMargin(c.Margin), getMargin(c.Baseline.Margin()), getMargin(c.MarginShift), c.TurnoutChange*100)
+}
+
+// getTurnoutLine shows the share of eligible voters counted so far, next to the turnout of the compared cycle when it's known
+func getTurnoutLine(vote *StateVote, year int) string {
+	turnout := vote.dem.StateVote.TurnoutPercentage
+
+	if turnout == 0 {
+		return ""
+	}
+
+	line := getPrinter().Sprintf("🗳 Turnout %.1f%% of eligible voters", turnout*100)
+
+	if previous, ok := election.GetHistoricalTurnout(year, vote.dem.State.Abbreviation); ok {
+		line += fmt.Sprintf(" (%d: %.1f%%)", year, previous*100)
+	}
+
+	return line + "\n"
+}
+
+// getMargin formats a Democratic margin the way election desks do, D+1.2 or R+0.7
+func getMargin(margin float64) string {
+	if margin < 0 {
+		return fmt.Sprintf("R+%.1f", -margin*100)
+	}
+
+	return fmt.Sprintf("D+%.1f", margin*100)
+}
+
+// getSinceLine sums up the votes added since the previous edit, "+12,345 votes since last update (🐴 +7,000 🐘 +5,345)"
+func getSinceLine(vote *StateVote) string {
+	if vote.since == nil || vote.since.Votes() <= 0 {
+		return ""
+	}
+
+	line := getPrinter().Sprintf("+%d votes since last update (", vote.since.Votes())
+
+	for i, c := range vote.since.Candidates {
+		if i > 0 {
+			line += " "
+		}
+
+		line += getPrinter().Sprintf("%s %+d", c.Candidate.Party.Symbol, c.Votes())
+	}
+
+	return line + ")\n"
+}
+
+// getCountyBlock lists the counties the latest votes came from, at most three of them
+func getCountyBlock(vote *StateVote) string {
+	block := ""
+
+	for i, delta := range vote.counties {
+		if i == 3 {
+			break
+		}
+
+		block += getPrinter().Sprintf("+%s votes from %s, %.0f%% %s\n",
+			getShortCount(delta.Votes), delta.County.Name, delta.LeaderShare()*100, getCandidateLabel(delta.Leader))
+	}
+
+	return block
+}
+
+// getShortCount formats vote counts the way people say them, 40k rather than 40,123
+func getShortCount(count int64) string {
+	switch {
+	case count >= 1000000:
+		return getPrinter().Sprintf("%.1fM", float64(count)/1000000)
+	case count >= 1000:
+		return getPrinter().Sprintf("%.0fk", float64(count)/1000)
+	default:
+		return getPrinter().Sprintf("%d", count)
+	}
+}
+
+func getCandidateLabel(candidate election.Candidate) string {
+	if candidate.Party.Abbreviation != "" {
+		return candidate.Party.Abbreviation
+	}
+
+	return candidate.LastName
 }
 
 func getFormattedTime() string {
+	return getFormattedTimeOf(time.Now())
+}
+
+func getFormattedTimeOf(t time.Time) string {
 	loc, _ := time.LoadLocation("America/New_York")
-	str := time.Now().In(loc).Format("15:04 MST")
+	str := t.In(loc).Format("15:04 MST")
 
 	return str
 }
 
 func getCandidateBlock(vote election.Vote) string {
 	return getPrinter().Sprintf(
-		`%s <b>%s</b>
+		`%s <b>%s</b>%s
 	Votes: %d (%.2f%%)
 	Electoral Votes: %d
-`, vote.Candidate.Party.Symbol, vote.Candidate.LastName, vote.Count, vote.Percentage*100, vote.ElectoralVotes)
+`, vote.Candidate.Party.Symbol, vote.Candidate.LastName, getIncumbentMark(vote.Candidate), vote.Count, vote.Percentage*100, vote.ElectoralVotes)
+}
+
+// getIncumbentMark marks the incumbent the way ballots and election desks do
+func getIncumbentMark(candidate election.Candidate) string {
+	if candidate.Incumbent {
+		return " (i)"
+	}
+
+	return ""
 }
 
 func getPeekable(vote *StateVote) string {
@@ -301,8 +732,21 @@ func getPeekable(vote *StateVote) string {
 }
 
 type StateVote struct {
-	dem election.Vote
-	rep election.Vote
+	dem      election.Vote
+	rep      election.Vote
+	counties []election.CountyDelta
+	recount  election.RecountStatus
+	since    *election.StateDiff // What changed since the previous edit
+	shift    *election.BlueShift // How the margin moved since the first count
+}
+
+// getLeader returns the candidate ahead first
+func (s *StateVote) getLeader() (election.Vote, election.Vote) {
+	if s.rep.Count > s.dem.Count {
+		return s.rep, s.dem
+	}
+
+	return s.dem, s.rep
 }
 
 type EditableMessage struct {
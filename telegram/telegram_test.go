@@ -0,0 +1,186 @@
+package telegram_test
+
+import (
+	"github.com/aaomidi/uselections-2020/data"
+	"github.com/aaomidi/uselections-2020/election"
+	"github.com/aaomidi/uselections-2020/scraper"
+	"github.com/aaomidi/uselections-2020/scraper/nprtest"
+	"github.com/aaomidi/uselections-2020/store"
+	"github.com/aaomidi/uselections-2020/telegram"
+	"github.com/aaomidi/uselections-2020/telegram/telegramtest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInlineMessageIsEditedThroughFloodLimit(t *testing.T) {
+	api := telegramtest.NewServer()
+	defer api.Close()
+
+	npr := nprtest.NewServer(nprtest.LeadFlip())
+	defer npr.Close()
+	npr.Advance(0)
+
+	// every message exists already, so the bot doesn't spend a few minutes sending them
+	s := store.NewMemory()
+	for i, state := range election.GetStates() {
+		_ = s.SaveMessageIdForState(api.Channel.ID, state.Abbreviation, 100+i)
+	}
+	_ = s.SaveSummaryMessageId(api.Channel.ID, 99)
+
+	d := data.New(data.Settings{CloseMargin: 0.005, PercentTolerance: 0.02, MaxJump: 0.5, MinJumpVotes: 50000})
+	d.Start(data.Source{
+		Name:     "npr",
+		Scraper:  scraper.NewNPRScraper(time.Second, scraper.AllStatesURLAt(npr.URL)),
+		Schedule: &data.Schedule{Interval: 10 * time.Millisecond, Min: 10 * time.Millisecond, Max: 10 * time.Millisecond},
+	})
+	defer d.Stop()
+
+	bot := telegram.New(telegramtest.Token, "@uselections", s, d, telegram.Settings{
+		APIURL:         api.URL,
+		UpdateInterval: 10 * time.Millisecond,
+	})
+
+	if err := bot.Create(); err != nil {
+		t.Fatalf("could not create the bot: %v", err)
+	}
+
+	go bot.Start()
+	defer bot.Stop()
+
+	// the first round of edits reaches the channel messages of the four states in the scenario and the summary
+	if calls := api.WaitCalls("editMessageText", 5, 5*time.Second); len(calls) != 5 {
+		t.Fatalf("expected 5 edits, got %d", len(calls))
+	}
+
+	summary := waitAudit(t, s, store.SummaryAudit, 1)
+
+	if targets := summary[0].Targets; len(targets) != 1 || targets[0].MessageID != "99" || targets[0].Error != "" {
+		t.Errorf("expected the summary message 99 audited as sent, got %+v", targets)
+	}
+
+	queryID := api.InlineQuery(42, "pennsylvania")
+
+	answers := api.WaitCalls("answerInlineQuery", 1, 5*time.Second)
+	if len(answers) != 1 {
+		t.Fatal("the inline query wasn't answered")
+	}
+
+	if id := answers[0].Param("inline_query_id"); id != queryID {
+		t.Errorf("answered query %q, expected %q", id, queryID)
+	}
+
+	if results := answers[0].Param("results"); !strings.Contains(results, `"id":"PA"`) || !strings.Contains(results, "Biden") {
+		t.Errorf("expected Pennsylvania's results in the answer, got %s", results)
+	}
+
+	api.ChosenInlineResult(42, "PA", "pennsylvania", "inline-1")
+	waitInlineMessage(t, s, "PA", "inline-1")
+
+	api.FailWhere("editMessageText", "inline_message_id", "inline-1", telegramtest.TooManyRequests(1))
+	api.FailWhere("editMessageText", "inline_message_id", "inline-1", telegramtest.NotModified)
+
+	npr.Advance(15 * time.Second)
+
+	// the limited edit and its retry
+	inline := waitEdits(t, api, "inline_message_id", "inline-1", 2)
+
+	if len(inline) != 2 {
+		t.Fatalf("expected the inline message to be edited twice, got %d", len(inline))
+	}
+
+	if waited := inline[1].Time.Sub(inline[0].Time); waited < time.Second {
+		t.Errorf("retried after %s, Telegram asked for 1s", waited)
+	}
+
+	entries := waitAudit(t, s, "PA", 2)
+
+	channelID := strconv.Itoa(100 + stateIndex("PA"))
+	channel := waitEdits(t, api, "message_id", channelID, 2)
+
+	if chat := channel[len(channel)-1].Param("chat_id"); chat != strconv.FormatInt(api.Channel.ID, 10) {
+		t.Errorf("edited PA's message in chat %s, expected the channel", chat)
+	}
+
+	targets := entries[len(entries)-1].Targets
+	if len(targets) != 2 || targets[0].MessageID != channelID || targets[0].Unchanged || targets[0].Error != "" {
+		t.Fatalf("expected PA's channel message audited as sent, got %+v", targets)
+	}
+
+	if targets[1].MessageID != "inline-1" || !targets[1].Unchanged || targets[1].Error != "" {
+		t.Errorf("expected inline-1 audited as unchanged, got %+v", targets[1])
+	}
+}
+
+// waitEdits waits until n edits of a message were made, and returns them
+func waitEdits(t *testing.T, api *telegramtest.Server, param string, value string, n int) []telegramtest.Call {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		var edits []telegramtest.Call
+		for _, call := range api.Calls("editMessageText") {
+			if call.Param(param) == value {
+				edits = append(edits, call)
+			}
+		}
+
+		if len(edits) >= n || time.Now().After(deadline) {
+			return edits
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitAudit waits until n updates of a state were audited, and returns them
+func waitAudit(t *testing.T, s store.Store, state string, n int) []store.AuditEntry {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if entries, err := s.GetAudit(state, time.Time{}, time.Time{}); err == nil && len(entries) >= n {
+			return entries
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("fewer than %d updates of %s were audited", n, state)
+	return nil
+}
+
+// stateIndex is where a state is in the watched states
+func stateIndex(abbreviation string) int {
+	for i, state := range election.GetStates() {
+		if state.Abbreviation == abbreviation {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// waitInlineMessage waits until the bot saved the inline message of a state
+func waitInlineMessage(t *testing.T, s store.Store, state string, messageID string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		ids, _ := s.GetInlineMessageId(state)
+
+		for _, id := range ids {
+			if id == messageID {
+				return
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("inline message %s of %s was never saved", messageID, state)
+}
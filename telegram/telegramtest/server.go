@@ -0,0 +1,400 @@
+// Package telegramtest is a local stand-in for the Telegram Bot API, so the bot can be driven end to end without a real token.
+//
+// Point telegram.Settings.APIURL at Server.URL, queue updates with InlineQuery and ChosenInlineResult,
+// and assert on what the bot sent with Calls and WaitCalls.
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is accepted by the server, any other token is unauthorized
+const Token = "123456:TEST"
+
+// Call is a single Bot API request the bot made
+type Call struct {
+	Method string
+	Params map[string]interface{}
+	Time   time.Time
+}
+
+// Param returns a parameter as a string, telebot sends most of them that way
+func (c Call) Param(name string) string {
+	switch value := c.Params[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		raw, _ := json.Marshal(value)
+		return string(raw)
+	}
+}
+
+// Failure is an error the server answers a call with instead of handling it
+type Failure struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+// TooManyRequests is the flood limit error, asking to retry after the given seconds
+func TooManyRequests(retryAfter int) Failure {
+	return Failure{
+		Code:        http.StatusTooManyRequests,
+		Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+		RetryAfter:  retryAfter,
+	}
+}
+
+// NotModified is what Telegram answers when an edit doesn't change the message
+var NotModified = Failure{
+	Code:        http.StatusBadRequest,
+	Description: "Bad Request: message is not modified",
+}
+
+// Server records every call the bot makes and hands out the updates queued on it
+type Server struct {
+	// URL is the base URL to give the bot instead of https://api.telegram.org
+	URL string
+
+	// Channel is what getChat resolves every chat to
+	Channel tb.Chat
+
+	server *httptest.Server
+
+	mu            sync.Mutex
+	calls         []Call
+	failures      map[string][]failure
+	updates       []tb.Update
+	nextUpdateID  int
+	nextMessageID int
+	nextQueryID   int
+
+	// changed is closed and replaced whenever a call is recorded or an update is queued
+	changed chan struct{}
+	closed  chan struct{}
+}
+
+// NewServer starts a server listening on a random local port
+func NewServer() *Server {
+	s := &Server{
+		Channel: tb.Chat{
+			ID:       -1001234567890,
+			Type:     tb.ChatChannel,
+			Title:    "US Elections",
+			Username: "uselections",
+		},
+		failures:      make(map[string][]failure),
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		nextQueryID:   1,
+		changed:       make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close shuts the server down, pending getUpdates calls return right away
+func (s *Server) Close() {
+	close(s.closed)
+	s.server.Close()
+}
+
+// failure is a Failure waiting for the call it's for
+type failure struct {
+	Failure
+
+	// param and value pick the calls it's for, any call of the method when param is empty
+	param string
+	value string
+}
+
+// Fail makes the next call of method fail. Failures queued for the same method are used up in order.
+func (s *Server) Fail(method string, f Failure) {
+	s.FailWhere(method, "", "", f)
+}
+
+// FailWhere makes the next call of method fail that has the param set to value, like the edit of one message
+func (s *Server) FailWhere(method string, param string, value string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method] = append(s.failures[method], failure{Failure: f, param: param, value: value})
+}
+
+// InlineQuery queues an inline query from the user and returns its ID
+func (s *Server) InlineQuery(userID int, query string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := strconv.Itoa(s.nextQueryID)
+	s.nextQueryID++
+
+	s.queue(tb.Update{
+		Query: &tb.Query{
+			ID:   id,
+			From: tb.User{ID: userID},
+			Text: query,
+		},
+	})
+
+	return id
+}
+
+// ChosenInlineResult queues the user picking an inline result, which was sent as inlineMessageID
+func (s *Server) ChosenInlineResult(userID int, resultID string, query string, inlineMessageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queue(tb.Update{
+		ChosenInlineResult: &tb.ChosenInlineResult{
+			From:      tb.User{ID: userID},
+			ResultID:  resultID,
+			Query:     query,
+			MessageID: inlineMessageID,
+		},
+	})
+}
+
+// Message queues a private message from the user, for driving commands
+func (s *Server) Message(userID int, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := &tb.User{ID: userID}
+
+	s.queue(tb.Update{
+		Message: &tb.Message{
+			ID:       s.messageID(),
+			Sender:   user,
+			Unixtime: time.Now().Unix(),
+			Chat:     &tb.Chat{ID: int64(userID), Type: tb.ChatPrivate},
+			Text:     text,
+		},
+	})
+}
+
+// Calls returns the calls of method so far, oldest first. An empty method returns every call.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filter(method)
+}
+
+// WaitCalls waits until there are at least n calls of method and returns them.
+// It gives up after timeout and returns what there is.
+func (s *Server) WaitCalls(method string, n int, timeout time.Duration) []Call {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		calls := s.filter(method)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(calls) >= n {
+			return calls
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return calls
+		case <-s.closed:
+			return calls
+		}
+	}
+}
+
+func (s *Server) filter(method string) []Call {
+	result := make([]Call, 0)
+
+	for _, call := range s.calls {
+		if method == "" || call.Method == method {
+			result = append(result, call)
+		}
+	}
+
+	return result
+}
+
+// queue adds an update, the lock has to be held
+func (s *Server) queue(update tb.Update) {
+	update.ID = s.nextUpdateID
+	s.nextUpdateID++
+
+	s.updates = append(s.updates, update)
+	s.notify()
+}
+
+// notify wakes up everyone waiting on a change, the lock has to be held
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// messageID hands out the next message ID, the lock has to be held
+func (s *Server) messageID() int {
+	id := s.nextMessageID
+	s.nextMessageID++
+
+	return id
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// paths look like /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		reply(w, nil, &Failure{Code: http.StatusUnauthorized, Description: "Unauthorized"})
+		return
+	}
+
+	method := parts[1]
+	params := make(map[string]interface{})
+
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		reply(w, nil, &Failure{Code: http.StatusBadRequest, Description: "Bad Request: " + err.Error()})
+		return
+	}
+
+	if method == "getUpdates" {
+		s.getUpdates(w, params)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call := Call{
+		Method: method,
+		Params: params,
+		Time:   time.Now(),
+	}
+	s.calls = append(s.calls, call)
+	s.notify()
+
+	for i, f := range s.failures[method] {
+		if f.param != "" && call.Param(f.param) != f.value {
+			continue
+		}
+
+		s.failures[method] = append(s.failures[method][:i:i], s.failures[method][i+1:]...)
+		reply(w, nil, &f.Failure)
+		return
+	}
+
+	reply(w, s.result(method, params), nil)
+}
+
+// result is the successful answer to a call, the lock has to be held
+func (s *Server) result(method string, params map[string]interface{}) interface{} {
+	switch method {
+	case "getMe":
+		return tb.User{ID: 123456, IsBot: true, FirstName: "Elections", Username: "uselectionsbot"}
+	case "getChat":
+		return s.Channel
+	case "sendMessage":
+		return s.message(params)
+	case "editMessageText":
+		// inline messages aren't returned, only messages the bot sent to a chat
+		if _, inline := params["inline_message_id"]; inline {
+			return true
+		}
+
+		message := s.message(params)
+		if id, err := strconv.Atoi(fmt.Sprint(params["message_id"])); err == nil {
+			message.ID = id
+		}
+
+		return message
+	default:
+		return true
+	}
+}
+
+func (s *Server) message(params map[string]interface{}) tb.Message {
+	chat := s.Channel
+	if id, err := strconv.ParseInt(fmt.Sprint(params["chat_id"]), 10, 64); err == nil && id != chat.ID {
+		chat = tb.Chat{ID: id, Type: tb.ChatPrivate}
+	}
+
+	return tb.Message{
+		ID:       s.messageID(),
+		Unixtime: time.Now().Unix(),
+		Chat:     &chat,
+		Text:     fmt.Sprint(params["text"]),
+	}
+}
+
+// getUpdates long polls like the real API, answering as soon as there are updates at or after the offset
+func (s *Server) getUpdates(w http.ResponseWriter, params map[string]interface{}) {
+	offset, _ := strconv.Atoi(fmt.Sprint(params["offset"]))
+	timeout, _ := strconv.Atoi(fmt.Sprint(params["timeout"]))
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		updates := make([]tb.Update, 0)
+		for _, update := range s.updates {
+			if update.ID >= offset {
+				updates = append(updates, update)
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(updates) > 0 {
+			reply(w, updates, nil)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			reply(w, updates, nil)
+			return
+		case <-s.closed:
+			reply(w, updates, nil)
+			return
+		}
+	}
+}
+
+func reply(w http.ResponseWriter, result interface{}, failure *Failure) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if failure != nil {
+		// the field order matters, telebot matches errors with a regular expression
+		body := fmt.Sprintf(`{"ok":false,"error_code":%d,"description":%q`, failure.Code, failure.Description)
+		if failure.RetryAfter > 0 {
+			body += fmt.Sprintf(`,"parameters":{"retry_after":%d}`, failure.RetryAfter)
+		}
+
+		w.WriteHeader(failure.Code)
+		_, _ = w.Write([]byte(body + "}"))
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":     true,
+		"result": result,
+	})
+}
@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	tb "gopkg.in/tucnak/telebot.v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookSettings make Telegram push updates to us instead of us long polling for them
+type WebhookSettings struct {
+	// Listen is the address the listener binds to, ":8443" for example
+	Listen string
+
+	// PublicURL is where Telegram reaches the listener, without the secret path
+	PublicURL string
+
+	// SecretPath is the only path updates are accepted on, so nobody else can feed us updates.
+	// A random one is generated when it's empty.
+	SecretPath string
+
+	// TLSCert and TLSKey serve the listener over HTTPS, leave them empty behind a TLS terminating proxy
+	TLSCert string
+	TLSKey  string
+
+	// UploadCert sends TLSCert to Telegram, which is needed when it's self signed
+	UploadCert bool
+}
+
+// webhook receives updates on the secret path of our own listener and hands them to the bot as its poller.
+// telebot's own Webhook closes the stop channel that Bot.Start already closed, so it can't be stopped without a panic.
+type webhook struct {
+	settings WebhookSettings
+	endpoint *tb.WebhookEndpoint
+	server   *http.Server
+	log      *log.Entry
+
+	// mu guards dest, which is set once the bot starts polling
+	mu   sync.RWMutex
+	dest chan tb.Update
+}
+
+func newWebhook(settings WebhookSettings, logger *log.Entry) (*webhook, error) {
+	if settings.SecretPath == "" {
+		secret := make([]byte, 32)
+
+		if _, err := rand.Read(secret); err != nil {
+			return nil, NewError(err, "could not generate a webhook secret")
+		}
+
+		settings.SecretPath = hex.EncodeToString(secret)
+	}
+
+	path := "/" + strings.Trim(settings.SecretPath, "/")
+
+	endpoint := &tb.WebhookEndpoint{
+		PublicURL: strings.TrimRight(settings.PublicURL, "/") + path,
+	}
+
+	if settings.UploadCert {
+		endpoint.Cert = settings.TLSCert
+	}
+
+	w := &webhook{
+		settings: settings,
+		endpoint: endpoint,
+		log:      logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, w)
+
+	w.server = &http.Server{
+		Addr:              settings.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return w, nil
+}
+
+// register tells Telegram to push updates to the webhook
+func (w *webhook) register(b *tb.Bot) error {
+	if err := b.SetWebhook(&tb.Webhook{Endpoint: w.endpoint}); err != nil {
+		return NewError(err, "could not register the webhook")
+	}
+
+	return nil
+}
+
+// Poll passes updates on until the bot stops. Bot.Start closes stop itself, so it's only waited on here.
+func (w *webhook) Poll(b *tb.Bot, dest chan tb.Update, stop chan struct{}) {
+	w.mu.Lock()
+	w.dest = dest
+	w.mu.Unlock()
+
+	<-stop
+
+	w.mu.Lock()
+	w.dest = nil
+	w.mu.Unlock()
+}
+
+// ServeHTTP takes an update Telegram pushed to the secret path
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var update tb.Update
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.log.WithError(err).Warn("could not decode webhook update")
+		http.Error(rw, "bad update", http.StatusBadRequest)
+		return
+	}
+
+	w.mu.RLock()
+	dest := w.dest
+	w.mu.RUnlock()
+
+	if dest == nil {
+		// not polling yet or anymore, Telegram retries the update later
+		http.Error(rw, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case dest <- update:
+	case <-r.Context().Done():
+	}
+}
+
+// listen serves updates until shutdown is called
+func (w *webhook) listen() error {
+	var err error
+
+	if w.settings.TLSCert != "" {
+		err = w.server.ListenAndServeTLS(w.settings.TLSCert, w.settings.TLSKey)
+	} else {
+		err = w.server.ListenAndServe()
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+func (w *webhook) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return w.server.Shutdown(ctx)
+}